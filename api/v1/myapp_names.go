@@ -0,0 +1,28 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// CanaryName returns the name of the canary Deployment and Service of the Myapp.
+func (m *Myapp) CanaryName() string {
+	return m.Name + "-canary"
+}
+
+// ColorName returns the name of the Deployment and Service of a color of the
+// Myapp during blue/green rollouts.
+func (m *Myapp) ColorName(color Color) string {
+	return m.Name + "-" + string(color)
+}
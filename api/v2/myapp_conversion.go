@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// FooAnnotation holds the v1 spec.foo field, which has no v2 counterpart, so
+// that it survives a round trip through v2.
+const FooAnnotation = "webapp.my-apps.com/v1-foo"
+
+// ConvertTo converts this Myapp (v2) to the Hub version (v1).
+func (src *Myapp) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*webappv1.Myapp)
+	if !ok {
+		return fmt.Errorf("expected a v1 Myapp but got %T", dstRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if foo, ok := dst.Annotations[FooAnnotation]; ok {
+		dst.Spec.Foo = &foo
+		delete(dst.Annotations, FooAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	workload := src.Spec.Workload.DeepCopy()
+	dst.Spec.Image = workload.Image
+	dst.Spec.Replicas = workload.Replicas
+	dst.Spec.Port = workload.Port
+	dst.Spec.Env = workload.Env
+	dst.Spec.Resources = workload.Resources
+	dst.Spec.LivenessProbe = workload.Probes.Liveness
+	dst.Spec.ReadinessProbe = workload.Probes.Readiness
+
+	dst.Spec.Routing = nil
+	if routing := src.Spec.Routing.DeepCopy(); routing != nil {
+		dst.Spec.Routing = &webappv1.RoutingSpec{
+			Protocol:  webappv1.RouteProtocol(routing.Protocol),
+			Hostnames: routing.Hostnames,
+		}
+		for _, parentRef := range routing.ParentRefs {
+			dst.Spec.Routing.ParentRefs = append(dst.Spec.Routing.ParentRefs, webappv1.ParentRef(parentRef))
+		}
+		for _, path := range routing.Paths {
+			dst.Spec.Routing.Paths = append(dst.Spec.Routing.Paths, webappv1.PathMatch{
+				Type:  webappv1.PathMatchType(path.Type),
+				Value: path.Value,
+			})
+		}
+		for _, backendRef := range routing.BackendRefs {
+			dst.Spec.Routing.BackendRefs = append(dst.Spec.Routing.BackendRefs, webappv1.BackendRef(backendRef))
+		}
+		for _, method := range routing.Methods {
+			dst.Spec.Routing.Methods = append(dst.Spec.Routing.Methods, webappv1.MethodMatch{
+				Type:    webappv1.MethodMatchType(method.Type),
+				Service: method.Service,
+				Method:  method.Method,
+			})
+		}
+	}
+
+	dst.Spec.Rollout = nil
+	if rollout := src.Spec.Rollout.DeepCopy(); rollout != nil {
+		dst.Spec.Rollout = &webappv1.RolloutSpec{}
+		if rollout.Canary != nil {
+			dst.Spec.Rollout.Canary = &webappv1.CanaryStrategy{}
+			for _, step := range rollout.Canary.Steps {
+				dst.Spec.Rollout.Canary.Steps = append(dst.Spec.Rollout.Canary.Steps, webappv1.CanaryStep(step))
+			}
+		}
+		if rollout.BlueGreen != nil {
+			dst.Spec.Rollout.BlueGreen = (*webappv1.BlueGreenStrategy)(rollout.BlueGreen)
+		}
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = webappv1.MyappStatus{
+		Conditions:         status.Conditions,
+		ObservedGeneration: status.ObservedGeneration,
+		Image:              status.Image,
+		Replicas:           status.Replicas,
+		ReadyReplicas:      status.ReadyReplicas,
+		URL:                status.URL,
+	}
+	for _, ref := range status.BrokenBackendRefs {
+		dst.Status.BrokenBackendRefs = append(dst.Status.BrokenBackendRefs, webappv1.BrokenBackendRef(ref))
+	}
+	for _, ref := range status.BrokenParentRefs {
+		dst.Status.BrokenParentRefs = append(dst.Status.BrokenParentRefs, webappv1.BrokenParentRef(ref))
+	}
+	if canary := status.Canary; canary != nil {
+		dst.Status.Canary = &webappv1.CanaryStatus{
+			Revision:      canary.Revision,
+			Phase:         webappv1.CanaryPhase(canary.Phase),
+			Step:          canary.Step,
+			Weight:        canary.Weight,
+			StepStartTime: canary.StepStartTime,
+		}
+	}
+	if blueGreen := status.BlueGreen; blueGreen != nil {
+		dst.Status.BlueGreen = &webappv1.BlueGreenStatus{
+			ActiveColor:   webappv1.Color(blueGreen.ActiveColor),
+			PreviewColor:  webappv1.Color(blueGreen.PreviewColor),
+			ScaleDownTime: blueGreen.ScaleDownTime,
+		}
+	}
+	for _, drifted := range status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, webappv1.DriftedObject(drifted))
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1) to this version (v2).
+func (dst *Myapp) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*webappv1.Myapp)
+	if !ok {
+		return fmt.Errorf("expected a v1 Myapp but got %T", srcRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if src.Spec.Foo != nil {
+		if dst.Annotations == nil {
+			dst.Annotations = make(map[string]string, 1)
+		}
+		dst.Annotations[FooAnnotation] = *src.Spec.Foo
+	}
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec.Workload = WorkloadSpec{
+		Image:     spec.Image,
+		Replicas:  spec.Replicas,
+		Port:      spec.Port,
+		Env:       spec.Env,
+		Resources: spec.Resources,
+		Probes: ProbesSpec{
+			Liveness:  spec.LivenessProbe,
+			Readiness: spec.ReadinessProbe,
+		},
+	}
+
+	dst.Spec.Routing = nil
+	if routing := spec.Routing; routing != nil {
+		dst.Spec.Routing = &RoutingSpec{
+			Protocol:  RouteProtocol(routing.Protocol),
+			Hostnames: routing.Hostnames,
+		}
+		for _, parentRef := range routing.ParentRefs {
+			dst.Spec.Routing.ParentRefs = append(dst.Spec.Routing.ParentRefs, ParentRef(parentRef))
+		}
+		for _, path := range routing.Paths {
+			dst.Spec.Routing.Paths = append(dst.Spec.Routing.Paths, PathMatch{
+				Type:  PathMatchType(path.Type),
+				Value: path.Value,
+			})
+		}
+		for _, backendRef := range routing.BackendRefs {
+			dst.Spec.Routing.BackendRefs = append(dst.Spec.Routing.BackendRefs, BackendRef(backendRef))
+		}
+		for _, method := range routing.Methods {
+			dst.Spec.Routing.Methods = append(dst.Spec.Routing.Methods, MethodMatch{
+				Type:    MethodMatchType(method.Type),
+				Service: method.Service,
+				Method:  method.Method,
+			})
+		}
+	}
+
+	dst.Spec.Rollout = nil
+	if rollout := spec.Rollout; rollout != nil {
+		dst.Spec.Rollout = &RolloutSpec{}
+		if rollout.Canary != nil {
+			dst.Spec.Rollout.Canary = &CanaryStrategy{}
+			for _, step := range rollout.Canary.Steps {
+				dst.Spec.Rollout.Canary.Steps = append(dst.Spec.Rollout.Canary.Steps, CanaryStep(step))
+			}
+		}
+		if rollout.BlueGreen != nil {
+			dst.Spec.Rollout.BlueGreen = (*BlueGreenStrategy)(rollout.BlueGreen)
+		}
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = MyappStatus{
+		Conditions:         status.Conditions,
+		ObservedGeneration: status.ObservedGeneration,
+		Image:              status.Image,
+		Replicas:           status.Replicas,
+		ReadyReplicas:      status.ReadyReplicas,
+		URL:                status.URL,
+	}
+	for _, ref := range status.BrokenBackendRefs {
+		dst.Status.BrokenBackendRefs = append(dst.Status.BrokenBackendRefs, BrokenBackendRef(ref))
+	}
+	for _, ref := range status.BrokenParentRefs {
+		dst.Status.BrokenParentRefs = append(dst.Status.BrokenParentRefs, BrokenParentRef(ref))
+	}
+	if canary := status.Canary; canary != nil {
+		dst.Status.Canary = &CanaryStatus{
+			Revision:      canary.Revision,
+			Phase:         CanaryPhase(canary.Phase),
+			Step:          canary.Step,
+			Weight:        canary.Weight,
+			StepStartTime: canary.StepStartTime,
+		}
+	}
+	if blueGreen := status.BlueGreen; blueGreen != nil {
+		dst.Status.BlueGreen = &BlueGreenStatus{
+			ActiveColor:   Color(blueGreen.ActiveColor),
+			PreviewColor:  Color(blueGreen.PreviewColor),
+			ScaleDownTime: blueGreen.ScaleDownTime,
+		}
+	}
+	for _, drifted := range status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, DriftedObject(drifted))
+	}
+
+	return nil
+}
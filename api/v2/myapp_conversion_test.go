@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+	"sigs.k8s.io/randfill"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+func TestConversion(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Conversion Suite")
+}
+
+var _ = Describe("Myapp conversion", func() {
+	// fuzzIterations is the number of random objects converted in each direction.
+	const fuzzIterations = 200
+
+	var filler *randfill.Filler
+
+	BeforeEach(func() {
+		filler = randfill.NewWithSeed(GinkgoRandomSeed()).NilChance(0.3).NumElements(0, 3)
+	})
+
+	It("should make every served version convertible through the hub", func() {
+		scheme := runtime.NewScheme()
+		Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+		Expect(AddToScheme(scheme)).To(Succeed())
+
+		Expect(conversion.IsConvertible(scheme, &webappv1.Myapp{})).To(BeTrue())
+	})
+
+	It("should convert a v1 Myapp to v2", func() {
+		src := &webappv1.Myapp{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-webapp", Namespace: "tns"},
+			Spec: webappv1.MyappSpec{
+				Image:    "tusova194/my_test_app:1.0.5",
+				Replicas: ptr.To[int32](2),
+				Port:     3002,
+				Env:      []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+				},
+				ReadinessProbe: &corev1.Probe{InitialDelaySeconds: 5},
+				Routing: &webappv1.RoutingSpec{
+					ParentRefs: []webappv1.ParentRef{{Name: "gateway", SectionName: "http"}},
+					Hostnames:  []string{"test.my-apps.com"},
+					Paths:      []webappv1.PathMatch{{Type: webappv1.PathMatchPathPrefix, Value: "/test"}},
+				},
+				Foo: ptr.To("bar"),
+			},
+		}
+
+		dst := &Myapp{}
+		Expect(dst.ConvertFrom(src)).To(Succeed())
+
+		Expect(dst.Name).To(Equal("my-webapp"))
+		Expect(dst.Spec.Workload.Image).To(Equal("tusova194/my_test_app:1.0.5"))
+		Expect(dst.Spec.Workload.Replicas).To(Equal(ptr.To[int32](2)))
+		Expect(dst.Spec.Workload.Port).To(Equal(int32(3002)))
+		Expect(dst.Spec.Workload.Env).To(Equal(src.Spec.Env))
+		Expect(dst.Spec.Workload.Resources).To(Equal(src.Spec.Resources))
+		Expect(dst.Spec.Workload.Probes.Liveness).To(BeNil())
+		Expect(dst.Spec.Workload.Probes.Readiness).To(Equal(src.Spec.ReadinessProbe))
+		Expect(dst.Spec.Routing.ParentRefs).To(Equal([]ParentRef{{Name: "gateway", SectionName: "http"}}))
+		Expect(dst.Spec.Routing.Hostnames).To(Equal([]string{"test.my-apps.com"}))
+		Expect(dst.Spec.Routing.Paths).To(Equal([]PathMatch{{Type: PathMatchPathPrefix, Value: "/test"}}))
+		Expect(dst.Annotations).To(HaveKeyWithValue(FooAnnotation, "bar"))
+
+		By("not sharing memory with the source")
+		dst.Spec.Workload.Env[0].Value = "info"
+		Expect(src.Spec.Env[0].Value).To(Equal("debug"))
+	})
+
+	It("should round-trip v1 through v2 without losing fields", func() {
+		for range fuzzIterations {
+			original := &webappv1.Myapp{}
+			filler.Fill(original)
+
+			spoke := &Myapp{}
+			Expect(spoke.ConvertFrom(original.DeepCopy())).To(Succeed())
+			hub := &webappv1.Myapp{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+
+			// TypeMeta is set by the conversion webhook, not by the conversion functions
+			hub.TypeMeta = original.TypeMeta
+			Expect(equality.Semantic.DeepEqual(original, hub)).To(BeTrue(),
+				"round trip changed the object:\n%s", diff(original, hub))
+		}
+	})
+
+	It("should round-trip v2 through v1 without losing fields", func() {
+		for range fuzzIterations {
+			original := &Myapp{}
+			filler.Fill(original)
+			// The annotation is reserved for v1 objects
+			delete(original.Annotations, FooAnnotation)
+
+			hub := &webappv1.Myapp{}
+			Expect(original.DeepCopy().ConvertTo(hub)).To(Succeed())
+			spoke := &Myapp{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+
+			spoke.TypeMeta = original.TypeMeta
+			Expect(equality.Semantic.DeepEqual(original, spoke)).To(BeTrue(),
+				"round trip changed the object:\n%s", diff(original, spoke))
+		}
+	})
+})
+
+// diff describes the difference between two objects in a failure message.
+func diff(a, b any) string {
+	return format.Object(a, 1) + "\n---\n" + format.Object(b, 1)
+}
@@ -0,0 +1,537 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// MyappSpec defines the desired state of Myapp
+type MyappSpec struct {
+	// workload describes the application container and the Deployment running it.
+	// +required
+	Workload WorkloadSpec `json:"workload"`
+
+	// routing exposes the Myapp through the Gateway API. When set, a route named
+	// after the Myapp is generated with the Myapp Service as its backend.
+	// +optional
+	Routing *RoutingSpec `json:"routing,omitempty"`
+
+	// rollout selects how a change of the pod template is rolled out. Without
+	// it, the generated Deployment is updated in place.
+	// +optional
+	Rollout *RolloutSpec `json:"rollout,omitempty"`
+}
+
+// WorkloadSpec describes the Deployment and Service generated for a Myapp.
+type WorkloadSpec struct {
+	// image is the container image run by the generated Deployment.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Image string `json:"image"`
+
+	// replicas is the desired number of Pods of the generated Deployment.
+	// Defaults to 1 when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// port is the container port the application listens on. The generated
+	// Service exposes the same port. Defaults to 8080 when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// env is the list of environment variables set in the application container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// resources are the compute resources required by the application container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// probes are the health checks of the application container.
+	// +optional
+	Probes ProbesSpec `json:"probes,omitempty"`
+}
+
+// ProbesSpec groups the probes of the application container.
+type ProbesSpec struct {
+	// liveness is the liveness probe of the application container.
+	// +optional
+	Liveness *corev1.Probe `json:"liveness,omitempty"`
+
+	// readiness is the readiness probe of the application container.
+	// +optional
+	Readiness *corev1.Probe `json:"readiness,omitempty"`
+}
+
+// RoutingSpec describes the route generated for a Myapp.
+type RoutingSpec struct {
+	// protocol selects the kind of the generated route: an HTTPRoute for HTTP,
+	// a GRPCRoute for GRPC, and a TCPRoute or a TLSRoute, from the experimental
+	// Gateway API channel, for TCP and TLS. Defaults to HTTP.
+	// +optional
+	Protocol RouteProtocol `json:"protocol,omitempty"`
+
+	// parentRefs are the Gateways the generated route attaches to.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	// +required
+	ParentRefs []ParentRef `json:"parentRefs"`
+
+	// hostnames are the hostnames matched by the generated route, the SNI
+	// hostnames for TLS. TCP routes do not match hostnames.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// paths are the path matches routed to the Myapp, with the HTTP protocol.
+	// Defaults to a single PathPrefix match on "/" when empty.
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	Paths []PathMatch `json:"paths,omitempty"`
+
+	// methods are the gRPC service and method matches routed to the Myapp, with
+	// the GRPC protocol. Every service and method is routed when empty.
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	Methods []MethodMatch `json:"methods,omitempty"`
+
+	// backendRefs are Services the generated route sends traffic to alongside
+	// the Myapp Service. A Service in another namespace needs a ReferenceGrant
+	// in its namespace that allows routes from the namespace of the Myapp.
+	// +kubebuilder:validation:MaxItems=15
+	// +optional
+	BackendRefs []BackendRef `json:"backendRefs,omitempty"`
+}
+
+// RouteProtocol is the protocol a Myapp is routed with.
+// +kubebuilder:validation:Enum=HTTP;GRPC;TCP;TLS
+type RouteProtocol string
+
+const (
+	// RouteProtocolHTTP routes the Myapp through an HTTPRoute.
+	RouteProtocolHTTP RouteProtocol = "HTTP"
+	// RouteProtocolGRPC routes the Myapp through a GRPCRoute.
+	RouteProtocolGRPC RouteProtocol = "GRPC"
+	// RouteProtocolTCP routes the Myapp through a TCPRoute.
+	RouteProtocolTCP RouteProtocol = "TCP"
+	// RouteProtocolTLS routes the Myapp through a TLSRoute, passing TLS through
+	// to the application.
+	RouteProtocolTLS RouteProtocol = "TLS"
+)
+
+// ParentRef identifies a Gateway, and optionally one of its listeners.
+type ParentRef struct {
+	// name is the name of the Gateway.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// namespace is the namespace of the Gateway. Defaults to the namespace of the Myapp.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// sectionName is the name of the Gateway listener to attach to.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// BackendRef identifies a Service port the generated route sends traffic to.
+type BackendRef struct {
+	// name is the name of the Service.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// namespace is the namespace of the Service. Defaults to the namespace of the Myapp.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// port is the Service port traffic is sent to.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +required
+	Port int32 `json:"port"`
+
+	// weight is the share of the traffic sent to the Service, relative to the
+	// weights of the other backends. The Myapp Service has a weight of 1.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// PathMatchType is the type of an HTTP path match.
+// +kubebuilder:validation:Enum=Exact;PathPrefix
+type PathMatchType string
+
+const (
+	// PathMatchExact matches the request path exactly.
+	PathMatchExact PathMatchType = "Exact"
+	// PathMatchPathPrefix matches the request path by its prefix split by "/".
+	PathMatchPathPrefix PathMatchType = "PathPrefix"
+)
+
+// PathMatch describes an HTTP request path match.
+type PathMatch struct {
+	// type is the type of the match. Defaults to PathPrefix.
+	// +optional
+	Type PathMatchType `json:"type,omitempty"`
+
+	// value is the path to match against.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	// +required
+	Value string `json:"value"`
+}
+
+// MethodMatchType is the type of a gRPC method match.
+// +kubebuilder:validation:Enum=Exact;RegularExpression
+type MethodMatchType string
+
+const (
+	// MethodMatchExact matches the gRPC service and method exactly.
+	MethodMatchExact MethodMatchType = "Exact"
+	// MethodMatchRegularExpression matches the gRPC service and method against regular expressions.
+	MethodMatchRegularExpression MethodMatchType = "RegularExpression"
+)
+
+// MethodMatch describes a gRPC request service and method match. At least one
+// of service and method must be set.
+type MethodMatch struct {
+	// type is the type of the match. Defaults to Exact.
+	// +optional
+	Type MethodMatchType `json:"type,omitempty"`
+
+	// service is the fully qualified gRPC service to match, any service when empty.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// method is the gRPC method to match, any method when empty.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	Method string `json:"method,omitempty"`
+}
+
+// RolloutSpec describes how a new version of the Myapp is rolled out.
+type RolloutSpec struct {
+	// canary runs the new version in a second Deployment and Service, named
+	// after the Myapp with a -canary suffix, and shifts traffic to it through
+	// the weights of the route backendRefs, so it requires routing.
+	// +optional
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+
+	// blueGreen runs the new version in the inactive one of two Deployments
+	// and Services, named after the Myapp with a -blue and a -green suffix, and
+	// switches the route to it in a single update once it is available. It
+	// requires routing and cannot be combined with canary.
+	// +optional
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+}
+
+// BlueGreenStrategy describes how a blue/green rollout switches colors.
+type BlueGreenStrategy struct {
+	// autoPromote switches the route to the new version as soon as it is
+	// available. When false, the switch waits for the Myapp to be promoted.
+	// Defaults to true.
+	// +optional
+	AutoPromote *bool `json:"autoPromote,omitempty"`
+
+	// scaleDownDelay is how long the previous color keeps running after the
+	// switch, so that switching back is immediate. Defaults to 30s.
+	// +optional
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+
+	// previewHostnames are the hostnames of a route named after the Myapp with
+	// a -preview suffix, which sends traffic to the new version before the
+	// switch. No preview route is generated when empty, or with the TCP protocol.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	PreviewHostnames []string `json:"previewHostnames,omitempty"`
+}
+
+// Color is one of the two Deployments of a blue/green rollout.
+// +kubebuilder:validation:Enum=blue;green
+type Color string
+
+const (
+	// ColorBlue is the color of the Deployment and Service with the -blue suffix.
+	ColorBlue Color = "blue"
+	// ColorGreen is the color of the Deployment and Service with the -green suffix.
+	ColorGreen Color = "green"
+)
+
+// CanaryStrategy describes the traffic steps of a canary rollout.
+type CanaryStrategy struct {
+	// steps are the successive traffic shares of the new version. The rollout
+	// is completed, and the generated Deployment updated, after the last step.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=atomic
+	// +required
+	Steps []CanaryStep `json:"steps"`
+}
+
+// CanaryStep is a traffic share of a canary rollout.
+type CanaryStep struct {
+	// weight is the percentage of the traffic sent to the new version.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +required
+	Weight int32 `json:"weight"`
+
+	// pause is how long the step lasts before the next one starts. Without it,
+	// the rollout waits at this step until it is promoted.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// BrokenBackendRef describes a backendRef that does not resolve to a Service port.
+type BrokenBackendRef struct {
+	// kind is the kind of the route holding the backendRef, HTTPRoute when empty.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// route is the namespace/name of the route holding the backendRef.
+	Route string `json:"route"`
+
+	// rule is the index of the route rule holding the backendRef.
+	Rule int32 `json:"rule"`
+
+	// service is the namespace/name of the referenced Service.
+	Service string `json:"service"`
+
+	// port is the referenced Service port, unset when the backendRef has no port.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// reason is a machine readable reason the backendRef is broken.
+	Reason string `json:"reason"`
+
+	// message is a human readable description of the problem.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BrokenParentRef describes a route parentRef the referenced Gateway does not
+// attach the route to.
+type BrokenParentRef struct {
+	// kind is the kind of the route holding the parentRef.
+	Kind string `json:"kind"`
+
+	// route is the namespace/name of the route holding the parentRef.
+	Route string `json:"route"`
+
+	// gateway is the namespace/name of the referenced Gateway.
+	Gateway string `json:"gateway"`
+
+	// sectionName is the referenced listener, unset when the parentRef has none.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+
+	// reason is a machine readable reason the parentRef is broken.
+	Reason string `json:"reason"`
+
+	// message is a human readable description of the problem.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// MyappStatus defines the observed state of Myapp.
+type MyappStatus struct {
+	// conditions represent the latest available observations of the Myapp state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// observedGeneration is the most recent generation of the Myapp processed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// image is the image of the application container in the generated Deployment.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// replicas is the number of Pods of the generated Deployment.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// readyReplicas is the number of ready Pods of the generated Deployment.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// url is the address the application is served at: the first hostname, and
+	// path, of its route or, without routing, the cluster address of its Service.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// brokenBackendRefs lists the backendRefs of routes owned by the Myapp,
+	// or sending traffic to its Service, that cannot be resolved.
+	// +listType=atomic
+	// +optional
+	BrokenBackendRefs []BrokenBackendRef `json:"brokenBackendRefs,omitempty"`
+
+	// brokenParentRefs lists the parentRefs of routes owned by the Myapp, or
+	// sending traffic to its Service, that their Gateway does not attach them to.
+	// +listType=atomic
+	// +optional
+	BrokenParentRefs []BrokenParentRef `json:"brokenParentRefs,omitempty"`
+
+	// canary is the progress of the current, or last, canary rollout.
+	// +optional
+	Canary *CanaryStatus `json:"canary,omitempty"`
+
+	// blueGreen is the state of the blue/green rollout.
+	// +optional
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+
+	// drift lists the generated objects whose managed fields were last found
+	// changed by hand, and reverted, since the spec last changed.
+	// +listType=map
+	// +listMapKey=kind
+	// +listMapKey=name
+	// +optional
+	Drift []DriftedObject `json:"drift,omitempty"`
+}
+
+// DriftedObject describes the managed fields of a generated object that were
+// found changed by hand and reverted.
+type DriftedObject struct {
+	// kind is the kind of the object.
+	Kind string `json:"kind"`
+
+	// name is the name of the object.
+	Name string `json:"name"`
+
+	// fields lists the paths of the fields that drifted, such as spec.replicas.
+	// +listType=atomic
+	Fields []string `json:"fields"`
+
+	// lastDetectedTime is when the drift was last detected.
+	LastDetectedTime metav1.Time `json:"lastDetectedTime"`
+}
+
+// BlueGreenStatus describes the colors of a blue/green rollout.
+type BlueGreenStatus struct {
+	// activeColor is the color the route sends traffic to.
+	// +optional
+	ActiveColor Color `json:"activeColor,omitempty"`
+
+	// previewColor is the color running the new version, until the switch.
+	// +optional
+	PreviewColor Color `json:"previewColor,omitempty"`
+
+	// scaleDownTime is when the previous color is scaled down after a switch.
+	// +optional
+	ScaleDownTime *metav1.Time `json:"scaleDownTime,omitempty"`
+}
+
+// CanaryPhase is the phase of a canary rollout.
+// +kubebuilder:validation:Enum=Progressing;Paused;Promoting;Completed;Aborted
+type CanaryPhase string
+
+const (
+	// CanaryPhaseProgressing means the rollout moves to the next step when the
+	// pause of the current one has elapsed.
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhasePaused means the rollout waits at the current step until it is promoted.
+	CanaryPhasePaused CanaryPhase = "Paused"
+	// CanaryPhasePromoting means the generated Deployment is being updated to
+	// the new version while the canary still serves the traffic.
+	CanaryPhasePromoting CanaryPhase = "Promoting"
+	// CanaryPhaseCompleted means the generated Deployment runs the new version.
+	CanaryPhaseCompleted CanaryPhase = "Completed"
+	// CanaryPhaseAborted means the canary was removed and the generated
+	// Deployment kept at the previous version.
+	CanaryPhaseAborted CanaryPhase = "Aborted"
+)
+
+// CanaryStatus describes the progress of a canary rollout.
+type CanaryStatus struct {
+	// revision identifies the pod template rolled out by the canary.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// phase is the phase of the rollout.
+	// +optional
+	Phase CanaryPhase `json:"phase,omitempty"`
+
+	// step is the index of the current step in spec.rollout.canary.steps.
+	// +optional
+	Step int32 `json:"step,omitempty"`
+
+	// weight is the percentage of the traffic currently sent to the canary.
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+
+	// stepStartTime is when the current step started.
+	// +optional
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=ma,categories=all
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.status.image`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.replicas`,priority=1
+// +kubebuilder:printcolumn:name="Ready Replicas",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Canary",type=integer,JSONPath=`.status.canary.weight`,priority=1
+// +kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.blueGreen.activeColor`,priority=1
+// +kubebuilder:printcolumn:name="Preview",type=string,JSONPath=`.status.blueGreen.previewColor`,priority=1
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Myapp is the Schema for the myapps API
+type Myapp struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of Myapp
+	// +required
+	Spec MyappSpec `json:"spec"`
+
+	// status defines the observed state of Myapp
+	// +optional
+	Status MyappStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// MyappList contains a list of Myapp
+type MyappList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Myapp `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Myapp{}, &MyappList{})
+}
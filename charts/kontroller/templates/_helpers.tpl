@@ -57,3 +57,160 @@ Create the name of the ServiceAccount to use
 {{- default .Values.rbac.serviceAccountName | trunc 63 | trimSuffix "-" }}
 {{- end }}
 
+{{/*
+Rules granted to the controller in every watched namespace, or cluster-wide
+*/}}
+{{- define "kontroller.rules" }}
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - services
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - gateway.networking.k8s.io
+  resources:
+  - gatewayclasses
+  - gateways
+  - referencegrants
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - gateway.networking.k8s.io
+  resources:
+  - grpcroutes
+  - httproutes
+  - tcproutes
+  - tlsroutes
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - ingresses
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - webapp.my-apps.com
+  resources:
+  - myapps
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - webapp.my-apps.com
+  resources:
+  - myapps/finalizers
+  verbs:
+  - update
+- apiGroups:
+  - webapp.my-apps.com
+  resources:
+  - myapps/status
+  verbs:
+  - get
+  - patch
+  - update
+{{- end }}
+
+{{/*
+Arguments of the controller manager.
+*/}}
+{{- define "kontroller.args" -}}
+{{- with .Values.watchNamespaces }}
+- --watch-namespaces={{ join "," . }}
+{{- end }}
+{{- with .Values.watchNamespaceSelector }}
+- --watch-namespace-selector={{ . }}
+{{- end }}
+{{- with .Values.gatewayNamespaces }}
+- --gateway-namespaces={{ join "," . }}
+{{- end }}
+{{- with .Values.watchLabelSelector }}
+- --watch-label-selector={{ . }}
+{{- end }}
+{{- with .Values.serviceSelector.labels }}
+- --service-label-selector={{ . }}
+{{- end }}
+{{- with .Values.serviceSelector.fields }}
+- --service-field-selector={{ . }}
+{{- end }}
+{{- with .Values.httpRouteSelector.labels }}
+- --httproute-label-selector={{ . }}
+{{- end }}
+{{- with .Values.httpRouteSelector.fields }}
+- --httproute-field-selector={{ . }}
+{{- end }}
+{{- with .Values.grpcRouteSelector.labels }}
+- --grpcroute-label-selector={{ . }}
+{{- end }}
+{{- with .Values.grpcRouteSelector.fields }}
+- --grpcroute-field-selector={{ . }}
+{{- end }}
+{{- with .Values.tcpRouteSelector.labels }}
+- --tcproute-label-selector={{ . }}
+{{- end }}
+{{- with .Values.tcpRouteSelector.fields }}
+- --tcproute-field-selector={{ . }}
+{{- end }}
+{{- with .Values.tlsRouteSelector.labels }}
+- --tlsroute-label-selector={{ . }}
+{{- end }}
+{{- with .Values.tlsRouteSelector.fields }}
+- --tlsroute-field-selector={{ . }}
+{{- end }}
+{{- with .Values.defaultGateway }}
+- --default-gateway={{ . }}
+{{- end }}
+{{- if .Values.ingressMigration.enabled }}
+- --enable-ingress-migration
+{{- end }}
+{{- end }}
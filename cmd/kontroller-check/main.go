@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kontroller-check runs the reference checks of the controller on manifest
+// files, without a cluster. It builds the topology of the Services,
+// Deployments, Gateways, routes and Myapps it reads, together with the objects
+// the controller generates for the Myapps, prints the broken references and
+// invalid Myapps it finds, and exits with status 1 when any of them is an error.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"my-apps.com/myapp/internal/controller"
+	"my-apps.com/myapp/internal/manifests"
+	webhookv1 "my-apps.com/myapp/internal/webhook/v1"
+)
+
+// reasonInvalid is the reason of Myapps the validating webhook rejects.
+const reasonInvalid = "Invalid"
+
+// finding is a Finding with the file the object was read from.
+type finding struct {
+	controller.Finding
+	File string `json:"file,omitempty"`
+}
+
+// report is the JSON output.
+type report struct {
+	Findings []finding           `json:"findings"`
+	Topology controller.Topology `json:"topology"`
+}
+
+func main() {
+	var namespace, topologyNamespace, output, defaultGateway, allowedGateways string
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of namespaced objects that set none.")
+	flag.StringVar(&output, "output", "text",
+		"The output format: text or json for the findings, or dot or mermaid to draw the topology instead "+
+			"and print the findings to standard error.")
+	flag.StringVar(&topologyNamespace, "topology-namespace", "",
+		"If set, restricts the topology in the json, dot and mermaid output to this namespace.")
+	flag.StringVar(&defaultGateway, "default-gateway", "",
+		"The Gateway, as namespace/name, the controller attaches Myapps whose routing sets no parentRefs to.")
+	flag.StringVar(&allowedGateways, "allowed-gateways", "",
+		"Comma-separated list of namespace/name Gateways Myapps may attach their route to. Empty allows any Gateway.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] PATH...\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Checks the manifests in the given files and directories, or in standard input for -.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// The webhooks log every Myapp they default or validate
+	logf.SetLogger(zap.New(zap.WriteTo(io.Discard)))
+
+	if flag.NArg() == 0 || !slices.Contains([]string{"text", controller.TopologyFormatJSON,
+		controller.TopologyFormatDOT, controller.TopologyFormatMermaid}, output) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var opts webhookv1.Options
+	if defaultGateway != "" {
+		gateway, err := webhookv1.ParseGateway(defaultGateway)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --default-gateway value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.DefaultGateway = &gateway
+	}
+	var err error
+	if opts.AllowedGateways, err = webhookv1.ParseAllowedGateways(allowedGateways); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --allowed-gateways value: %v\n", err)
+		os.Exit(2)
+	}
+
+	m, err := manifests.Load(namespace, flag.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	topology, findings, err := check(m, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if topologyNamespace != "" {
+		topology = topology.ForNamespace(topologyNamespace)
+	}
+
+	switch output {
+	case controller.TopologyFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report{Findings: findings, Topology: topology})
+	case controller.TopologyFormatDOT:
+		err = topology.WriteDOT(os.Stdout)
+		printText(os.Stderr, findings)
+	case controller.TopologyFormatMermaid:
+		err = topology.WriteMermaid(os.Stdout)
+		printText(os.Stderr, findings)
+	default:
+		printText(os.Stdout, findings)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, f := range findings {
+		if f.Severity == controller.SeverityError {
+			os.Exit(1)
+		}
+	}
+}
+
+// check defaults and validates the Myapps the way the webhooks do, adds the
+// objects the controller generates for the valid ones and builds the topology.
+func check(m *manifests.Manifests, opts webhookv1.Options) (controller.Topology, []finding, error) {
+	ctx := context.Background()
+	defaulter := &webhookv1.MyappCustomDefaulter{DefaultGateway: opts.DefaultGateway}
+	validator := &webhookv1.MyappCustomValidator{AllowedGateways: opts.AllowedGateways}
+
+	findings := []finding{}
+	objs := m.Objects
+	for i := range objs.Myapps {
+		myapp := &objs.Myapps[i]
+		if err := defaulter.Default(ctx, myapp); err != nil {
+			return controller.Topology{}, nil, fmt.Errorf("failed to default Myapp %s/%s: %w", myapp.Namespace, myapp.Name, err)
+		}
+		if _, err := validator.ValidateCreate(ctx, myapp); err != nil {
+			findings = append(findings, finding{
+				Finding: controller.Finding{
+					Severity:  controller.SeverityError,
+					Kind:      "Myapp",
+					Namespace: myapp.Namespace,
+					Name:      myapp.Name,
+					Reason:    reasonInvalid,
+					Message:   err.Error(),
+				},
+				File: m.Source("Myapp", myapp.Namespace, myapp.Name),
+			})
+			continue
+		}
+		objs.AddGenerated(myapp)
+	}
+
+	topology := controller.BuildTopology(objs)
+	for _, f := range topology.Findings() {
+		file := m.Source(f.Kind, f.Namespace, f.Name)
+		// Objects generated for a Myapp are named after it
+		if file == "" {
+			file = m.Source("Myapp", f.Namespace, f.Name)
+		}
+		findings = append(findings, finding{Finding: f, File: file})
+	}
+	return topology, findings, nil
+}
+
+// printText prints one finding per line, prefixed with its file, and a summary.
+func printText(w io.Writer, findings []finding) {
+	var errors, warnings int
+	for _, f := range findings {
+		if f.File != "" {
+			fmt.Fprintf(w, "%s: ", f.File)
+		}
+		fmt.Fprintln(w, f.Finding)
+		if f.Severity == controller.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Fprintf(w, "%d errors, %d warnings\n", errors, warnings)
+}
@@ -17,30 +17,43 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayapischeme "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/scheme"
 
 	webappv1 "my-apps.com/myapp/api/v1"
+	webappv2 "my-apps.com/myapp/api/v2"
 	"my-apps.com/myapp/internal/controller"
+	webhookv1 "my-apps.com/myapp/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -53,6 +66,9 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(webappv1.AddToScheme(scheme))
+	utilruntime.Must(webappv2.AddToScheme(scheme))
+	// Registers the standard Gateway API kinds as well as the experimental
+	// v1alpha2 TCPRoutes and TLSRoutes.
 	utilruntime.Must(gatewayapischeme.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
@@ -66,6 +82,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var enableIngressMigration bool
+	var allowedGateways, defaultGateway string
+	var watchNamespaces, watchNamespaceSelector string
+	var gatewayNamespaces string
+	var watchLabelSelector string
+	var serviceLabelSelector, serviceFieldSelector string
+	var httpRouteLabelSelector, httpRouteFieldSelector string
+	var grpcRouteLabelSelector, grpcRouteFieldSelector string
+	var tcpRouteLabelSelector, tcpRouteFieldSelector string
+	var tlsRouteLabelSelector, tlsRouteFieldSelector string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -84,6 +110,48 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&allowedGateways, "allowed-gateways", "",
+		"Comma-separated namespace/name list of Gateways a Myapp may route through. "+
+			"Leave empty to allow every Gateway.")
+	flag.StringVar(&defaultGateway, "default-gateway", "",
+		"The namespace/name of the Gateway a Myapp routes through when its routing sets no parentRefs, "+
+			"and migrated Ingresses attach to when their annotation names no Gateway.")
+	flag.BoolVar(&enableIngressMigration, "enable-ingress-migration", false,
+		"If set, Ingresses annotated with kontroller.my-apps.com/migrate-to-gateway are migrated to HTTPRoutes.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of namespaces the controller watches. "+
+			"Leave empty, without --watch-namespace-selector, to watch the whole cluster.")
+	flag.StringVar(&watchNamespaceSelector, "watch-namespace-selector", "",
+		"Label selector of namespaces the controller watches, in addition to --watch-namespaces. "+
+			"Matching namespaces are resolved once at startup, which requires listing namespaces cluster-wide: "+
+			"namespaces labeled or created later are only watched after a restart.")
+	flag.StringVar(&gatewayNamespaces, "gateway-namespaces", "",
+		"Comma-separated list of namespaces holding Gateways the routes of the watched namespaces attach to. "+
+			"Gateways are read in these namespaces, and in those of --default-gateway and --allowed-gateways, "+
+			"in addition to the watched namespaces.")
+	flag.StringVar(&watchLabelSelector, "watch-label-selector", "",
+		"Label selector of the Services and routes the controller caches and reconciles on, "+
+			"for instance kontroller.my-apps.com/managed=true. Leave empty to watch all of them.")
+	flag.StringVar(&serviceLabelSelector, "service-label-selector", "",
+		"Label selector of the watched Services. Overrides --watch-label-selector.")
+	flag.StringVar(&serviceFieldSelector, "service-field-selector", "",
+		"Field selector of the watched Services.")
+	flag.StringVar(&httpRouteLabelSelector, "httproute-label-selector", "",
+		"Label selector of the watched HTTPRoutes. Overrides --watch-label-selector.")
+	flag.StringVar(&httpRouteFieldSelector, "httproute-field-selector", "",
+		"Field selector of the watched HTTPRoutes.")
+	flag.StringVar(&grpcRouteLabelSelector, "grpcroute-label-selector", "",
+		"Label selector of the watched GRPCRoutes. Overrides --watch-label-selector.")
+	flag.StringVar(&grpcRouteFieldSelector, "grpcroute-field-selector", "",
+		"Field selector of the watched GRPCRoutes.")
+	flag.StringVar(&tcpRouteLabelSelector, "tcproute-label-selector", "",
+		"Label selector of the watched TCPRoutes. Overrides --watch-label-selector.")
+	flag.StringVar(&tcpRouteFieldSelector, "tcproute-field-selector", "",
+		"Field selector of the watched TCPRoutes.")
+	flag.StringVar(&tlsRouteLabelSelector, "tlsroute-label-selector", "",
+		"Label selector of the watched TLSRoutes. Overrides --watch-label-selector.")
+	flag.StringVar(&tlsRouteFieldSelector, "tlsroute-field-selector", "",
+		"Field selector of the watched TLSRoutes.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -201,21 +269,142 @@ func main() {
 		// LeaderElectionReleaseOnCancel: true,
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	var defaultGatewayKey *types.NamespacedName
+	if defaultGateway != "" {
+		gateway, err := webhookv1.ParseGateway(defaultGateway)
+		if err != nil {
+			setupLog.Error(err, "invalid --default-gateway value")
+			os.Exit(1)
+		}
+		defaultGatewayKey = &gateway
+	}
+	allowedGatewayKeys, err := webhookv1.ParseAllowedGateways(allowedGateways)
+	if err != nil {
+		setupLog.Error(err, "invalid --allowed-gateways value")
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+
+	namespaces, err := resolveWatchNamespaces(context.Background(), restConfig, watchNamespaces, watchNamespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "unable to resolve the watched namespaces")
+		os.Exit(1)
+	}
+	if len(namespaces) > 0 {
+		// Namespaces matching the selector later are not added, so the resolved set is logged
+		setupLog.Info("Restricting the cache to the watched namespaces", "namespaces", namespaces,
+			"namespaceSelector", watchNamespaceSelector)
+		options.Cache.DefaultNamespaces = make(map[string]cache.Config, len(namespaces))
+		for _, namespace := range namespaces {
+			options.Cache.DefaultNamespaces[namespace] = cache.Config{}
+		}
+	}
+
+	options.Cache.ByObject = map[client.Object]cache.ByObject{}
+	if len(namespaces) > 0 {
+		// Shared Gateways usually live outside the namespaces of the routes
+		// attached to them
+		gatewayCache := cache.ByObject{Namespaces: make(map[string]cache.Config)}
+		for _, namespace := range namespaces {
+			gatewayCache.Namespaces[namespace] = cache.Config{}
+		}
+		for _, namespace := range strings.Split(gatewayNamespaces, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				gatewayCache.Namespaces[namespace] = cache.Config{}
+			}
+		}
+		for _, gateway := range allowedGatewayKeys {
+			gatewayCache.Namespaces[gateway.Namespace] = cache.Config{}
+		}
+		if defaultGatewayKey != nil {
+			gatewayCache.Namespaces[defaultGatewayKey.Namespace] = cache.Config{}
+		}
+		options.Cache.ByObject[&gatewayv1.Gateway{}] = gatewayCache
+	}
+	// watchFilter parses the selectors of a kind, falling back to --watch-label-selector,
+	// and restricts the cache of that kind to the matching objects.
+	watchFilter := func(kind string, obj client.Object, labelSelector, fieldSelector string) controller.WatchFilter {
+		if labelSelector == "" {
+			labelSelector = watchLabelSelector
+		}
+		filter, err := controller.ParseWatchFilter(labelSelector, fieldSelector)
+		if err != nil {
+			setupLog.Error(err, "unable to parse the selectors", "kind", kind)
+			os.Exit(1)
+		}
+		if !filter.IsEmpty() {
+			setupLog.Info("Filtering the watched objects", "kind", kind, "labels", labelSelector, "fields", fieldSelector)
+			options.Cache.ByObject[obj] = filter.ByObject()
+		}
+		return filter
+	}
+	serviceFilter := watchFilter("Service", &corev1.Service{}, serviceLabelSelector, serviceFieldSelector)
+	httpRouteFilter := watchFilter("HTTPRoute", &gatewayv1.HTTPRoute{}, httpRouteLabelSelector, httpRouteFieldSelector)
+	grpcRouteFilter := watchFilter("GRPCRoute", &gatewayv1.GRPCRoute{}, grpcRouteLabelSelector, grpcRouteFieldSelector)
+	tcpRouteFilter := watchFilter("TCPRoute", &gatewayv1alpha2.TCPRoute{}, tcpRouteLabelSelector, tcpRouteFieldSelector)
+	tlsRouteFilter := watchFilter("TLSRoute", &gatewayv1alpha2.TLSRoute{}, tlsRouteLabelSelector, tlsRouteFieldSelector)
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
 	if err := (&controller.MyappReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("myapp-controller"),
+
+		APIReader:       mgr.GetAPIReader(),
+		ServiceFilter:   serviceFilter,
+		HTTPRouteFilter: httpRouteFilter,
+		GRPCRouteFilter: grpcRouteFilter,
+		TCPRouteFilter:  tcpRouteFilter,
+		TLSRouteFilter:  tlsRouteFilter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Myapp")
 		os.Exit(1)
 	}
+	if enableIngressMigration {
+		if err := (&controller.IngressReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("ingress-migration"),
+
+			DefaultGateway:  defaultGatewayKey,
+			APIReader:       mgr.GetAPIReader(),
+			ServiceFilter:   serviceFilter,
+			HTTPRouteFilter: httpRouteFilter,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Ingress")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		webhookOpts := webhookv1.Options{
+			AllowedGateways: allowedGatewayKeys,
+			DefaultGateway:  defaultGatewayKey,
+		}
+		if err := webhookv1.SetupMyappWebhookWithManager(mgr, webhookOpts); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Myapp")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	// The topology is served by the metrics server, behind the same authn/authz filter.
+	if err := mgr.AddMetricsServerExtraHandler(controller.TopologyPath,
+		&controller.TopologyHandler{
+			Reader:        mgr.GetCache(),
+			APIReader:     mgr.GetAPIReader(),
+			ServiceFilter: serviceFilter,
+		}); err != nil {
+		setupLog.Error(err, "unable to add topology handler to metrics server")
+		os.Exit(1)
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
@@ -247,3 +436,50 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// resolveWatchNamespaces returns the namespaces listed in names, a
+// comma-separated list, together with the namespaces matching selector. An
+// empty result means that the whole cluster is watched.
+func resolveWatchNamespaces(ctx context.Context, cfg *rest.Config, names, selector string) ([]string, error) {
+	var namespaces []string
+	seen := make(map[string]bool)
+	add := func(namespace string) {
+		if namespace != "" && !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	for _, namespace := range strings.Split(names, ",") {
+		add(strings.TrimSpace(namespace))
+	}
+
+	if selector == "" {
+		return namespaces, nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --watch-namespace-selector: %w", err)
+	}
+
+	// The manager cache does not exist yet, so namespaces are listed directly
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	var list corev1.NamespaceList
+	if err := c.List(ctx, &list, client.MatchingLabelsSelector{Selector: parsed}); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces matching %q: %w", selector, err)
+	}
+	for _, namespace := range list.Items {
+		add(namespace.Name)
+	}
+
+	// Falling back to the whole cluster would defeat the purpose of the selector
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("no namespace matches --watch-namespace-selector %q", selector)
+	}
+	return namespaces, nil
+}
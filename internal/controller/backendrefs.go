@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// brokenBackendRef is a route backendRef that does not resolve to a Service port.
+type brokenBackendRef struct {
+	kind    string
+	route   types.NamespacedName
+	rule    int
+	service types.NamespacedName
+	port    int32
+	reason  string
+	message string
+}
+
+// toStatus converts the broken backendRef to its Myapp status representation.
+func (b brokenBackendRef) toStatus() webappv1.BrokenBackendRef {
+	return webappv1.BrokenBackendRef{
+		Kind:    b.kind,
+		Route:   b.route.String(),
+		Rule:    int32(b.rule),
+		Service: b.service.String(),
+		Port:    b.port,
+		Reason:  b.reason,
+		Message: b.message,
+	}
+}
+
+// checkBackendRefs cross-references the Service backendRefs of every rule of
+// the given routes against the given Services and returns those that point at
+// a missing Service or at a port the Service does not expose, and those that
+// point at another namespace without a ReferenceGrant allowing it. BackendRefs
+// to kinds other than Service are not checked.
+func checkBackendRefs(
+	routes []client.Object, services []corev1.Service, grants []gatewayv1beta1.ReferenceGrant,
+) []brokenBackendRef {
+	servicesByKey := make(map[types.NamespacedName]*corev1.Service, len(services))
+	for i := range services {
+		servicesByKey[types.NamespacedName{Namespace: services[i].Namespace, Name: services[i].Name}] = &services[i]
+	}
+
+	var broken []brokenBackendRef
+	for _, route := range routes {
+		kind := routeKindOf(route)
+		routeKey := client.ObjectKeyFromObject(route)
+		for ruleIndex, backendRefs := range routeBackendRefs(route) {
+			for _, backendRef := range backendRefs {
+				serviceKey, ok := backendServiceKey(route.GetNamespace(), backendRef)
+				if !ok {
+					continue
+				}
+
+				ref := brokenBackendRef{kind: kind, route: routeKey, rule: ruleIndex, service: serviceKey}
+				if backendRef.Port != nil {
+					ref.port = int32(*backendRef.Port)
+				}
+
+				service, found := servicesByKey[serviceKey]
+				switch {
+				case !backendRefPermitted(kind, route.GetNamespace(), serviceKey, grants):
+					ref.reason = webappv1.BackendReasonRefNotPermitted
+					ref.message = fmt.Sprintf("no ReferenceGrant in namespace %s allows %ss from namespace %s to reference Service %s",
+						serviceKey.Namespace, kind, route.GetNamespace(), serviceKey.Name)
+				case !found:
+					ref.reason = webappv1.BackendReasonServiceNotFound
+					ref.message = fmt.Sprintf("Service %s does not exist", serviceKey)
+				case backendRef.Port == nil:
+					ref.reason = webappv1.BackendReasonPortNotFound
+					ref.message = fmt.Sprintf("backendRef to Service %s has no port", serviceKey)
+				case !servicePortExists(service, ref.port):
+					ref.reason = webappv1.BackendReasonPortNotFound
+					ref.message = fmt.Sprintf("Service %s does not expose port %d", serviceKey, ref.port)
+				default:
+					continue
+				}
+				broken = append(broken, ref)
+			}
+		}
+	}
+	return broken
+}
+
+// servicePortExists reports whether the Service exposes the given port.
+func servicePortExists(service *corev1.Service, port int32) bool {
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedServiceKeys returns the Services generated for a Myapp: the Myapp
+// Service, named after the Myapp, its canary Service and its color Services.
+func generatedServiceKeys(myapp *webappv1.Myapp) map[types.NamespacedName]bool {
+	names := []string{
+		myapp.Name, myapp.CanaryName(), myapp.ColorName(webappv1.ColorBlue), myapp.ColorName(webappv1.ColorGreen),
+	}
+	keys := make(map[types.NamespacedName]bool, len(names))
+	for _, name := range names {
+		keys[types.NamespacedName{Namespace: myapp.Namespace, Name: name}] = true
+	}
+	return keys
+}
+
+// brokenBackendRefsFor returns the broken backendRefs relevant to a Myapp: those
+// of routes it owns and those pointing at a Service generated for it.
+func brokenBackendRefsFor(myapp *webappv1.Myapp, routes []client.Object, broken []brokenBackendRef) []brokenBackendRef {
+	myappKey := types.NamespacedName{Namespace: myapp.Namespace, Name: myapp.Name}
+	services := generatedServiceKeys(myapp)
+
+	type routeRef struct {
+		kind string
+		key  types.NamespacedName
+	}
+	ownedRoutes := make(map[routeRef]bool)
+	for _, route := range routes {
+		if owner, ok := owningMyapp(route); ok && owner == myappKey {
+			ownedRoutes[routeRef{kind: routeKindOf(route), key: client.ObjectKeyFromObject(route)}] = true
+		}
+	}
+
+	var relevant []brokenBackendRef
+	for _, ref := range broken {
+		if ownedRoutes[routeRef{kind: ref.kind, key: ref.route}] || services[ref.service] {
+			relevant = append(relevant, ref)
+		}
+	}
+	return relevant
+}
+
+// upsertObject replaces the object with the same namespace and name in objs, or
+// appends it. It is used to overlay objects just written on cached lists.
+func upsertObject[T any, PT interface {
+	*T
+	client.Object
+}](objs []T, obj T) []T {
+	key := client.ObjectKeyFromObject(PT(&obj))
+	for i := range objs {
+		if client.ObjectKeyFromObject(PT(&objs[i])) == key {
+			objs[i] = obj
+			return objs
+		}
+	}
+	return append(objs, obj)
+}
@@ -0,0 +1,416 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+const (
+	// colorLabel tells the Pods of the two Deployments of a blue/green rollout apart.
+	colorLabel = "kontroller.my-apps.com/color"
+	// revisionAnnotation holds the revision of the Pod template last written
+	// to the generated Deployment or to a color Deployment. Live templates
+	// carry fields defaulted by the API server, so they are compared to the
+	// desired one through it.
+	revisionAnnotation = "kontroller.my-apps.com/revision"
+)
+
+// blueGreenRollout is the outcome of reconciling the colors of a Myapp.
+type blueGreenRollout struct {
+	// holdStable keeps the Pod template of the generated Deployment while it
+	// serves the Myapp and the first color starts.
+	holdStable bool
+	// active is the Deployment of the active color, which replaces the
+	// generated Deployment. It is nil while the generated Deployment serves the Myapp.
+	active *appsv1.Deployment
+	// selector replaces the selector of the Myapp Service, nil to keep the default.
+	selector map[string]string
+	// services are the color Services written for the Myapp.
+	services []corev1.Service
+	// requeueAfter is when the previous color is scaled down, zero when no
+	// scale-down is pending.
+	requeueAfter time.Duration
+}
+
+// colorSelectorLabels returns the labels used to select the Pods of a color of
+// a Myapp. Like for canaries, they lack myappLabel.
+func colorSelectorLabels(myapp *webappv1.Myapp, color webappv1.Color) map[string]string {
+	return map[string]string{
+		nameLabel:  myapp.Name,
+		colorLabel: string(color),
+	}
+}
+
+// otherColor returns the color a new version is rolled out to. The first
+// color is blue.
+func otherColor(color webappv1.Color) webappv1.Color {
+	if color == webappv1.ColorBlue {
+		return webappv1.ColorGreen
+	}
+	return webappv1.ColorBlue
+}
+
+// blueGreenStrategyFor returns the blue/green strategy of a Myapp, or nil when
+// it is not rolled out by switching colors. A canary strategy takes precedence.
+func blueGreenStrategyFor(myapp *webappv1.Myapp) *webappv1.BlueGreenStrategy {
+	if myapp.Spec.Routing == nil || myapp.Spec.Rollout == nil || myapp.Spec.Rollout.Canary != nil {
+		return nil
+	}
+	return myapp.Spec.Rollout.BlueGreen
+}
+
+// scaleDownDelayFor returns the scale-down delay of a blue/green strategy, applying the default.
+func scaleDownDelayFor(strategy *webappv1.BlueGreenStrategy) time.Duration {
+	if strategy.ScaleDownDelay == nil {
+		return webappv1.DefaultScaleDownDelay
+	}
+	return strategy.ScaleDownDelay.Duration
+}
+
+// servingServiceName returns the name of the Service the route of a Myapp
+// sends its traffic to: the Service of the active color during a blue/green
+// rollout, the Myapp Service otherwise.
+func servingServiceName(myapp *webappv1.Myapp) string {
+	if blueGreen := myapp.Status.BlueGreen; blueGreen != nil && blueGreen.ActiveColor != "" {
+		return myapp.ColorName(blueGreen.ActiveColor)
+	}
+	return myapp.Name
+}
+
+// podRevision returns the revision of the Pod template generated for a Myapp.
+func podRevision(myapp *webappv1.Myapp) (string, error) {
+	var deployment appsv1.Deployment
+	mutateDeployment(myapp, &deployment)
+	return templateRevision(&deployment.Spec.Template)
+}
+
+// reconcileBlueGreen drives the blue/green rollout of the Myapp. The active
+// color serves the Myapp; a new revision of the Pod template is rolled out to
+// the other color, the preview, and the route is switched to it once it is
+// available and, without autoPromote, promoted. The previous color keeps
+// running for the scale-down delay, so that switching back is immediate. An
+// existing generated Deployment serves the Myapp until the first switch.
+func (r *MyappReconciler) reconcileBlueGreen(ctx context.Context, myapp *webappv1.Myapp) (blueGreenRollout, error) {
+	strategy := blueGreenStrategyFor(myapp)
+	if strategy == nil {
+		return r.stopBlueGreen(ctx, myapp)
+	}
+	revision, err := podRevision(myapp)
+	if err != nil {
+		return blueGreenRollout{}, err
+	}
+
+	var generated appsv1.Deployment
+	err = r.Get(ctx, client.ObjectKeyFromObject(myapp), &generated)
+	if client.IgnoreNotFound(err) != nil {
+		return blueGreenRollout{}, fmt.Errorf("failed to get Deployment %s/%s: %w", myapp.Namespace, myapp.Name, err)
+	}
+	status := myapp.Status.BlueGreen
+	if status == nil {
+		status = &webappv1.BlueGreenStatus{}
+		myapp.Status.BlueGreen = status
+	}
+	if status.ActiveColor == "" && apierrors.IsNotFound(err) {
+		// Nothing serves the Myapp yet, the first color starts as the active one
+		status.ActiveColor = webappv1.ColorBlue
+	}
+
+	now := metav1.Now()
+	rollout := blueGreenRollout{holdStable: true}
+	if status.ActiveColor != "" {
+		if rollout.active, err = r.applyColor(ctx, myapp, status.ActiveColor, revision, true); err != nil {
+			return blueGreenRollout{}, err
+		}
+		rollout.selector = colorSelectorLabels(myapp, status.ActiveColor)
+	}
+
+	if rollout.active != nil && rollout.active.Annotations[revisionAnnotation] == revision {
+		status.PreviewColor = ""
+		if rollout.requeueAfter, err = r.scaleDownPreviousColor(ctx, myapp, now); err != nil {
+			return blueGreenRollout{}, err
+		}
+		rollout.services, err = r.applyColorServices(ctx, myapp, status.ActiveColor)
+		return rollout, err
+	}
+
+	// The new revision runs in the other color until the switch
+	preview := otherColor(status.ActiveColor)
+	status.PreviewColor = preview
+	status.ScaleDownTime = nil
+	previewDeployment, err := r.applyColor(ctx, myapp, preview, revision, false)
+	if err != nil {
+		return blueGreenRollout{}, err
+	}
+
+	available, _ := deploymentAvailable(myapp, previewDeployment)
+	_, promote := myapp.Annotations[webappv1.PromoteAnnotation]
+	if available && (promote || strategy.AutoPromote == nil || *strategy.AutoPromote) {
+		if promote {
+			if err := r.removeAnnotations(ctx, myapp, webappv1.PromoteAnnotation); err != nil {
+				return blueGreenRollout{}, err
+			}
+			status = myapp.Status.BlueGreen
+		}
+		delay := scaleDownDelayFor(strategy)
+		r.recordRolloutEvent(myapp, "BlueGreenSwitched", "Switched the route from %s to %s, running revision %s",
+			servingServiceName(myapp), myapp.ColorName(preview), revision)
+		status.ActiveColor = preview
+		status.PreviewColor = ""
+		status.ScaleDownTime = &metav1.Time{Time: now.Add(delay)}
+		rollout.active = previewDeployment
+		rollout.selector = colorSelectorLabels(myapp, preview)
+		rollout.requeueAfter = delay
+	}
+
+	colors := []webappv1.Color{status.ActiveColor, status.PreviewColor}
+	rollout.services, err = r.applyColorServices(ctx, myapp, colors...)
+	return rollout, err
+}
+
+// stopBlueGreen moves a Myapp whose blue/green strategy was removed back to
+// its generated Deployment. The active color keeps serving the Myapp until the
+// generated Deployment is available; the colors are then deleted once the
+// route no longer sends traffic to them.
+func (r *MyappReconciler) stopBlueGreen(ctx context.Context, myapp *webappv1.Myapp) (blueGreenRollout, error) {
+	status := myapp.Status.BlueGreen
+	if status == nil || status.ActiveColor == "" {
+		myapp.Status.BlueGreen = nil
+		return blueGreenRollout{}, nil
+	}
+
+	var generated appsv1.Deployment
+	err := r.Get(ctx, client.ObjectKeyFromObject(myapp), &generated)
+	if client.IgnoreNotFound(err) != nil {
+		return blueGreenRollout{}, fmt.Errorf("failed to get Deployment %s/%s: %w", myapp.Namespace, myapp.Name, err)
+	}
+	if err == nil {
+		if available, _ := deploymentAvailable(myapp, &generated); available {
+			myapp.Status.BlueGreen = nil
+			return blueGreenRollout{}, nil
+		}
+	}
+	status.PreviewColor = ""
+	return blueGreenRollout{selector: colorSelectorLabels(myapp, status.ActiveColor)}, nil
+}
+
+// applyColor creates or updates the Deployment of a color of the Myapp. With
+// hold, the Pod template of an existing Deployment is kept; a new Deployment,
+// or one that is not held, runs the given revision.
+func (r *MyappReconciler) applyColor(
+	ctx context.Context, myapp *webappv1.Myapp, color webappv1.Color, revision string, hold bool,
+) (*appsv1.Deployment, error) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: myapp.ColorName(color), Namespace: myapp.Namespace},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		if err := r.checkControlled(myapp, deployment); err != nil {
+			return err
+		}
+		held := hold && deployment.ResourceVersion != ""
+		template := deployment.Spec.Template.DeepCopy()
+		podLabels := colorSelectorLabels(myapp, color)
+		podLabels[managedByLabel] = managedByValue
+		mutatePods(myapp, deployment, replicasFor(myapp), colorSelectorLabels(myapp, color), podLabels)
+		if held {
+			deployment.Spec.Template = *template
+		} else {
+			metav1.SetMetaDataAnnotation(&deployment.ObjectMeta, revisionAnnotation, revision)
+		}
+		return controllerutil.SetControllerReference(myapp, deployment, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile Deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+	}
+	logf.FromContext(ctx).Info("Reconciled color Deployment", "name", deployment.Name, "operation", op)
+	r.recordWrite(myapp, deployment, op)
+	return deployment, nil
+}
+
+// applyColorServices creates or updates the Services of the given colors of
+// the Myapp and returns them. Empty colors are skipped.
+func (r *MyappReconciler) applyColorServices(
+	ctx context.Context, myapp *webappv1.Myapp, colors ...webappv1.Color,
+) ([]corev1.Service, error) {
+	var services []corev1.Service
+	for _, color := range colors {
+		if color == "" {
+			continue
+		}
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: myapp.ColorName(color), Namespace: myapp.Namespace},
+		}
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+			if err := r.checkControlled(myapp, service); err != nil {
+				return err
+			}
+			mutateService(myapp, service)
+			service.Spec.Selector = colorSelectorLabels(myapp, color)
+			return controllerutil.SetControllerReference(myapp, service, r.Scheme)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile Service %s/%s: %w", service.Namespace, service.Name, err)
+		}
+		logf.FromContext(ctx).Info("Reconciled color Service", "name", service.Name, "operation", op)
+		r.recordWrite(myapp, service, op)
+		services = append(services, *service)
+	}
+	return services, nil
+}
+
+// scaleDownPreviousColor scales the previous color of the Myapp down to zero
+// replicas, and deletes the generated Deployment it may have replaced, once
+// the scale-down delay has elapsed. It returns the time left otherwise.
+func (r *MyappReconciler) scaleDownPreviousColor(
+	ctx context.Context, myapp *webappv1.Myapp, now metav1.Time,
+) (time.Duration, error) {
+	status := myapp.Status.BlueGreen
+	if status.ScaleDownTime == nil {
+		return 0, nil
+	}
+	if remaining := status.ScaleDownTime.Sub(now.Time); remaining > 0 {
+		return remaining, nil
+	}
+
+	previous := myapp.ColorName(otherColor(status.ActiveColor))
+	var deployment appsv1.Deployment
+	err := r.Get(ctx, client.ObjectKey{Namespace: myapp.Namespace, Name: previous}, &deployment)
+	if client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("failed to get Deployment %s/%s: %w", myapp.Namespace, previous, err)
+	}
+	if err == nil && metav1.IsControlledBy(&deployment, myapp) && ptr.Deref(deployment.Spec.Replicas, 1) != 0 {
+		patch := client.MergeFrom(deployment.DeepCopy())
+		deployment.Spec.Replicas = ptr.To[int32](0)
+		if err := r.Patch(ctx, &deployment, patch); err != nil {
+			return 0, fmt.Errorf("failed to scale down Deployment %s/%s: %w", myapp.Namespace, previous, err)
+		}
+	}
+
+	generated := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(myapp), generated); err == nil && metav1.IsControlledBy(generated, myapp) {
+		if err := r.Delete(ctx, generated); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to delete Deployment %s/%s: %w", myapp.Namespace, myapp.Name, err)
+		}
+		r.recordDelete(myapp, generated)
+	}
+
+	status.ScaleDownTime = nil
+	r.recordRolloutEvent(myapp, "BlueGreenScaledDown", "Scaled down the previous color %s", previous)
+	return 0, nil
+}
+
+// reconcilePreviewRoute creates or updates the preview route of the Myapp,
+// which sends the traffic of the preview hostnames to the preview color, and
+// deletes it when there is no preview.
+func (r *MyappReconciler) reconcilePreviewRoute(ctx context.Context, myapp *webappv1.Myapp) error {
+	name := myapp.Name + "-preview"
+
+	var route client.Object
+	strategy := blueGreenStrategyFor(myapp)
+	status := myapp.Status.BlueGreen
+	if strategy != nil && len(strategy.PreviewHostnames) > 0 && status != nil && status.PreviewColor != "" &&
+		protocolFor(myapp.Spec.Routing) != webappv1.RouteProtocolTCP {
+		route = newRoute(protocolFor(myapp.Spec.Routing))
+	}
+	for _, protocol := range routeProtocols {
+		stale := newRoute(protocol)
+		if route != nil && routeKindOf(stale) == routeKindOf(route) {
+			continue
+		}
+		stale.SetName(name)
+		if err := r.deleteRoute(ctx, myapp, stale); err != nil {
+			return err
+		}
+	}
+	if route == nil {
+		return nil
+	}
+
+	// The preview route is the route of a Myapp served by the preview color
+	// on the preview hostnames
+	preview := myapp.DeepCopy()
+	preview.Spec.Routing.Hostnames = strategy.PreviewHostnames
+	preview.Spec.Routing.BackendRefs = nil
+	preview.Status.BlueGreen.ActiveColor = status.PreviewColor
+
+	kind := routeKindOf(route)
+	route.SetName(name)
+	route.SetNamespace(myapp.Namespace)
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, route, func() error {
+		if err := r.checkControlled(myapp, route); err != nil {
+			return err
+		}
+		mutateRoute(preview, route)
+		return controllerutil.SetControllerReference(myapp, route, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile %s %s/%s: %w", kind, route.GetNamespace(), route.GetName(), err)
+	}
+	logf.FromContext(ctx).Info("Reconciled preview "+kind, "name", route.GetName(), "operation", op)
+	r.recordWrite(myapp, route, op)
+	return nil
+}
+
+// deleteColors deletes the color Deployments and Services of the Myapp, if
+// any. It is called once the route no longer sends traffic to them.
+func (r *MyappReconciler) deleteColors(ctx context.Context, myapp *webappv1.Myapp) error {
+	for _, color := range []webappv1.Color{webappv1.ColorBlue, webappv1.ColorGreen} {
+		key := client.ObjectKey{Namespace: myapp.Namespace, Name: myapp.ColorName(color)}
+		for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+			if err := r.Get(ctx, key, obj); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return fmt.Errorf("failed to get %s %s: %w", r.kindOf(obj), key, err)
+			}
+			if !metav1.IsControlledBy(obj, myapp) {
+				continue
+			}
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete %s %s: %w", r.kindOf(obj), key, err)
+			}
+			logf.FromContext(ctx).Info("Deleted color "+r.kindOf(obj), "name", key.Name)
+			r.recordDelete(myapp, obj)
+		}
+	}
+	return nil
+}
+
+// setBlueGreenConditions reports a pending blue/green switch through the
+// Progressing condition.
+func setBlueGreenConditions(myapp *webappv1.Myapp) {
+	status := myapp.Status.BlueGreen
+	if status == nil || status.PreviewColor == "" {
+		return
+	}
+	setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionTrue, webappv1.ReasonBlueGreenPreviewing,
+		fmt.Sprintf("Running the new version on %s before switching the route from %s",
+			myapp.ColorName(status.PreviewColor), servingServiceName(myapp)))
+}
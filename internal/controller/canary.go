@@ -0,0 +1,359 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+const (
+	// trackLabel tells the Pods of the canary Deployment of a Myapp apart from
+	// the Pods of its generated Deployment.
+	trackLabel  = "kontroller.my-apps.com/track"
+	canaryTrack = "canary"
+)
+
+// canaryRollout is the outcome of reconciling the canary of a Myapp.
+type canaryRollout struct {
+	// holdStable keeps the Pod template of the generated Deployment unchanged
+	// while the canary runs the new one.
+	holdStable bool
+	// service is the canary Service, nil when no canary runs.
+	service *corev1.Service
+	// requeueAfter is when the pause of the current step elapses, zero when
+	// the rollout does not wait for time to pass.
+	requeueAfter time.Duration
+}
+
+// canarySelectorLabels returns the labels used to select the canary Pods of a
+// Myapp. They lack myappLabel, so that the generated Deployment and Service
+// never select canary Pods.
+func canarySelectorLabels(myapp *webappv1.Myapp) map[string]string {
+	return map[string]string{
+		nameLabel:  myapp.Name,
+		trackLabel: canaryTrack,
+	}
+}
+
+// canaryStrategyFor returns the canary strategy of a Myapp, or nil when the
+// Myapp is updated in place. Canaries need a route to shift traffic with.
+func canaryStrategyFor(myapp *webappv1.Myapp) *webappv1.CanaryStrategy {
+	if myapp.Spec.Routing == nil || myapp.Spec.Rollout == nil {
+		return nil
+	}
+	return myapp.Spec.Rollout.Canary
+}
+
+// canaryActive reports whether a canary rollout sends traffic to the canary.
+func canaryActive(canary *webappv1.CanaryStatus) bool {
+	if canary == nil {
+		return false
+	}
+	switch canary.Phase {
+	case webappv1.CanaryPhaseProgressing, webappv1.CanaryPhasePaused, webappv1.CanaryPhasePromoting:
+		return true
+	}
+	return false
+}
+
+// canaryReplicas returns the replicas of the canary Deployment: the share of
+// the Myapp replicas matching the canary traffic weight, at least one Pod.
+// While the generated Deployment is updated, the canary runs every replica.
+func canaryReplicas(myapp *webappv1.Myapp, canary *webappv1.CanaryStatus) int32 {
+	replicas := replicasFor(myapp)
+	if canary.Phase == webappv1.CanaryPhasePromoting || replicas == 0 {
+		return replicas
+	}
+	return max(1, (replicas*canary.Weight+99)/100)
+}
+
+// templateRevision returns a short hash identifying a Pod template.
+func templateRevision(template *corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash Pod template: %w", err)
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write(data)
+	return fmt.Sprintf("%08x", hash.Sum32()), nil
+}
+
+// setCanaryStep moves a canary rollout to the given step, or to the Promoting
+// phase past the last step, and records the weight and phase of the step.
+func setCanaryStep(canary *webappv1.CanaryStatus, strategy *webappv1.CanaryStrategy, step int32, now metav1.Time) {
+	if int(step) >= len(strategy.Steps) {
+		canary.Phase = webappv1.CanaryPhasePromoting
+		canary.Weight = 100
+		canary.StepStartTime = &now
+		return
+	}
+	if step != canary.Step || canary.StepStartTime == nil {
+		canary.StepStartTime = &now
+	}
+	canary.Step = step
+	canary.Weight = strategy.Steps[step].Weight
+	canary.Phase = webappv1.CanaryPhaseProgressing
+	if strategy.Steps[step].Pause == nil {
+		canary.Phase = webappv1.CanaryPhasePaused
+	}
+}
+
+// reconcileCanary drives the canary rollout of the Myapp. A change of the Pod
+// template of its generated Deployment starts a canary running the new
+// template, while the generated Deployment keeps the previous one. The canary
+// then goes through the steps of the strategy, moving on when a pause elapses
+// or when the Myapp is promoted, and the generated Deployment is updated after
+// the last step. The canary is removed once the generated Deployment is
+// available, or when the rollout is aborted. Progress is recorded in the
+// Myapp status, from which the route weights are derived.
+func (r *MyappReconciler) reconcileCanary(ctx context.Context, myapp *webappv1.Myapp) (canaryRollout, error) {
+	strategy := canaryStrategyFor(myapp)
+	if strategy == nil {
+		myapp.Status.Canary = nil
+		return canaryRollout{}, nil
+	}
+	value, promote := myapp.Annotations[webappv1.PromoteAnnotation]
+	_, abort := myapp.Annotations[webappv1.AbortAnnotation]
+	if promote || abort {
+		if err := r.removeAnnotations(ctx, myapp, webappv1.PromoteAnnotation, webappv1.AbortAnnotation); err != nil {
+			return canaryRollout{}, err
+		}
+	}
+	promoteFull := promote && value == webappv1.PromoteFull
+
+	var stable appsv1.Deployment
+	if err := r.Get(ctx, client.ObjectKeyFromObject(myapp), &stable); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return canaryRollout{}, fmt.Errorf("failed to get Deployment %s/%s: %w", myapp.Namespace, myapp.Name, err)
+		}
+		// A new Deployment is created with the current template right away
+		myapp.Status.Canary = nil
+		return canaryRollout{}, nil
+	}
+	if !metav1.IsControlledBy(&stable, myapp) {
+		// Reported as a conflict when reconciling the workload
+		return canaryRollout{}, nil
+	}
+	revision, err := podRevision(myapp)
+	if err != nil {
+		return canaryRollout{}, err
+	}
+	applied, found := stable.Annotations[revisionAnnotation]
+	changed := applied != revision
+	if !found {
+		// Deployments written before the revision was recorded
+		desired := stable.DeepCopy()
+		mutateDeployment(myapp, desired)
+		changed = !equality.Semantic.DeepEqual(stable.Spec.Template, desired.Spec.Template)
+	}
+
+	now := metav1.Now()
+	canary := myapp.Status.Canary
+	if canary == nil || canary.Revision != revision {
+		if !changed {
+			// The generated Deployment already runs the template, e.g. the
+			// spec was reverted during a rollout
+			if canaryActive(canary) {
+				canary.Phase = webappv1.CanaryPhaseAborted
+				canary.Weight = 0
+				r.recordRolloutEvent(myapp, "CanaryAborted",
+					"Canary rollout of revision %s superseded by the current revision", canary.Revision)
+			}
+			return canaryRollout{}, nil
+		}
+		canary = &webappv1.CanaryStatus{Revision: revision}
+		myapp.Status.Canary = canary
+		setCanaryStep(canary, strategy, 0, now)
+		r.recordRolloutEvent(myapp, "CanaryStarted", "Started canary rollout of revision %s with %d%% of the traffic",
+			revision, canary.Weight)
+	}
+
+	switch {
+	case canary.Phase == webappv1.CanaryPhaseCompleted:
+		return canaryRollout{}, nil
+	case canary.Phase == webappv1.CanaryPhaseAborted:
+		return canaryRollout{holdStable: true}, nil
+	case abort:
+		canary.Phase = webappv1.CanaryPhaseAborted
+		canary.Weight = 0
+		r.recordRolloutEvent(myapp, "CanaryAborted", "Aborted canary rollout of revision %s at step %d",
+			revision, canary.Step)
+		return canaryRollout{holdStable: true}, nil
+	case canary.Phase == webappv1.CanaryPhasePromoting:
+	case promoteFull:
+		setCanaryStep(canary, strategy, int32(len(strategy.Steps)), now)
+	case promote:
+		setCanaryStep(canary, strategy, canary.Step+1, now)
+	case int(canary.Step) >= len(strategy.Steps):
+		// Steps were removed from the strategy during the rollout
+		setCanaryStep(canary, strategy, canary.Step, now)
+	case canary.Phase == webappv1.CanaryPhaseProgressing && canary.StepStartTime != nil &&
+		!now.Time.Before(canary.StepStartTime.Add(strategy.Steps[canary.Step].Pause.Duration)):
+		setCanaryStep(canary, strategy, canary.Step+1, now)
+	default:
+		// Pick up changes to the current step
+		setCanaryStep(canary, strategy, canary.Step, now)
+	}
+
+	// The generated Deployment is only known to run the new template when it
+	// was up to date before this reconcile
+	available, _ := deploymentAvailable(myapp, &stable)
+	rollout := canaryRollout{holdStable: true}
+	switch {
+	case canary.Phase == webappv1.CanaryPhasePromoting && !changed && available:
+		canary.Phase = webappv1.CanaryPhaseCompleted
+		canary.Weight = 0
+		r.recordRolloutEvent(myapp, "CanaryCompleted", "Completed canary rollout of revision %s", revision)
+		return canaryRollout{}, nil
+	case canary.Phase == webappv1.CanaryPhasePromoting:
+		// The canary serves the traffic until the generated Deployment runs
+		// the new template, its status changes trigger the next reconciles
+		rollout.holdStable = false
+	case canary.Phase == webappv1.CanaryPhaseProgressing:
+		pause := strategy.Steps[canary.Step].Pause.Duration
+		rollout.requeueAfter = max(time.Second, canary.StepStartTime.Add(pause).Sub(now.Time))
+	}
+	if rollout.service, err = r.applyCanary(ctx, myapp, canary); err != nil {
+		return canaryRollout{}, err
+	}
+	return rollout, nil
+}
+
+// removeAnnotations removes handled rollout annotations from the Myapp. It is
+// called before any progress is made, so that an annotation is never handled twice.
+func (r *MyappReconciler) removeAnnotations(ctx context.Context, myapp *webappv1.Myapp, names ...string) error {
+	// The patch response carries the stored status, not the one being computed
+	status := myapp.Status.DeepCopy()
+	patch := client.MergeFrom(myapp.DeepCopy())
+	for _, name := range names {
+		delete(myapp.Annotations, name)
+	}
+	if err := r.Patch(ctx, myapp, patch); err != nil {
+		return fmt.Errorf("failed to remove rollout annotations: %w", err)
+	}
+	myapp.Status = *status
+	logf.FromContext(ctx).Info("Handled rollout annotations", "annotations", names)
+	return nil
+}
+
+// applyCanary creates or updates the canary Deployment and Service of the
+// Myapp and returns the Service.
+func (r *MyappReconciler) applyCanary(
+	ctx context.Context, myapp *webappv1.Myapp, canary *webappv1.CanaryStatus,
+) (*corev1.Service, error) {
+	logger := logf.FromContext(ctx)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: myapp.CanaryName(), Namespace: myapp.Namespace},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		if err := r.checkControlled(myapp, deployment); err != nil {
+			return err
+		}
+		podLabels := canarySelectorLabels(myapp)
+		podLabels[managedByLabel] = managedByValue
+		mutatePods(myapp, deployment, canaryReplicas(myapp, canary), canarySelectorLabels(myapp), podLabels)
+		return controllerutil.SetControllerReference(myapp, deployment, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile Deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+	}
+	logger.Info("Reconciled canary Deployment", "name", deployment.Name, "operation", op)
+	r.recordWrite(myapp, deployment, op)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: myapp.CanaryName(), Namespace: myapp.Namespace},
+	}
+	op, err = controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		if err := r.checkControlled(myapp, service); err != nil {
+			return err
+		}
+		mutateService(myapp, service)
+		service.Spec.Selector = canarySelectorLabels(myapp)
+		return controllerutil.SetControllerReference(myapp, service, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile Service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	logger.Info("Reconciled canary Service", "name", service.Name, "operation", op)
+	r.recordWrite(myapp, service, op)
+
+	return service, nil
+}
+
+// deleteCanary deletes the canary Deployment and Service of the Myapp, if any.
+// It is called once the route no longer sends traffic to them.
+func (r *MyappReconciler) deleteCanary(ctx context.Context, myapp *webappv1.Myapp) error {
+	key := client.ObjectKey{Namespace: myapp.Namespace, Name: myapp.CanaryName()}
+	for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+		if err := r.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get canary %s %s: %w", r.kindOf(obj), key, err)
+		}
+		// Never delete an object that was not generated for this Myapp
+		if !metav1.IsControlledBy(obj, myapp) {
+			continue
+		}
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete canary %s %s: %w", r.kindOf(obj), key, err)
+		}
+		logf.FromContext(ctx).Info("Deleted canary "+r.kindOf(obj), "name", key.Name)
+		r.recordDelete(myapp, obj)
+	}
+	return nil
+}
+
+// recordRolloutEvent emits a Normal Event about the rollout of the Myapp.
+func (r *MyappReconciler) recordRolloutEvent(myapp *webappv1.Myapp, reason, messageFmt string, args ...any) {
+	r.eventf(myapp, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// setCanaryConditions reports an ongoing canary rollout through the
+// Progressing condition.
+func setCanaryConditions(myapp *webappv1.Myapp) {
+	canary := myapp.Status.Canary
+	switch {
+	case !canaryActive(canary):
+	case canary.Phase == webappv1.CanaryPhasePaused:
+		setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionTrue, webappv1.ReasonCanaryPaused,
+			fmt.Sprintf("Canary paused at step %d with %d%% of the traffic, waiting to be promoted",
+				canary.Step, canary.Weight))
+	case canary.Phase == webappv1.CanaryPhasePromoting:
+		setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionTrue, webappv1.ReasonCanaryProgressing,
+			"Canary promoted, updating the Deployment")
+	default:
+		setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionTrue, webappv1.ReasonCanaryProgressing,
+			fmt.Sprintf("Canary at step %d with %d%% of the traffic", canary.Step, canary.Weight))
+	}
+}
@@ -0,0 +1,653 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const (
+	// migrateAnnotation opts an Ingress in to its migration to the Gateway API.
+	// Its value is the Gateway the generated HTTPRoutes attach to, either as
+	// namespace/name or as the name of a Gateway in the Ingress namespace. An
+	// empty value selects the default Gateway of the manager.
+	migrateAnnotation = "kontroller.my-apps.com/migrate-to-gateway"
+
+	// maxRouteRules is the number of rules the Gateway API accepts in an HTTPRoute.
+	maxRouteRules = 16
+)
+
+// Reasons of the Events recorded on migrated Ingresses.
+const (
+	reasonMigrated                   = "Migrated"
+	reasonInvalidGateway             = "InvalidGateway"
+	reasonGatewayNotFound            = "GatewayNotFound"
+	reasonRouteConflict              = "RouteConflict"
+	reasonUnsupportedAnnotation      = "UnsupportedAnnotation"
+	reasonImplementationSpecificPath = "ImplementationSpecificPath"
+	reasonInvalidPath                = "InvalidPath"
+	reasonResourceBackend            = "ResourceBackend"
+	reasonUnresolvedPort             = "UnresolvedPort"
+	reasonTooManyRules               = "TooManyRules"
+	reasonTLSNotMigrated             = "TLSNotMigrated"
+)
+
+// errRouteConflict is returned when the HTTPRoute an Ingress migrates to
+// already exists and was not generated for the Ingress.
+var errRouteConflict = errors.New("HTTPRoute exists and is not controlled by the Ingress")
+
+// IngressReconciler migrates annotated Ingresses to the Gateway API: it
+// generates the HTTPRoutes equivalent to their hosts, paths and default
+// backend, and reports the features that do not translate as Events.
+type IngressReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// DefaultGateway is the Gateway the HTTPRoutes attach to when the
+	// migration annotation does not name one.
+	DefaultGateway *types.NamespacedName
+	// APIReader reads the backend Services ServiceFilter keeps out of the cache.
+	APIReader client.Reader
+	// ServiceFilter restricts the Services that are cached and trigger reconciles.
+	ServiceFilter WatchFilter
+	// HTTPRouteFilter restricts the HTTPRoutes that are cached and trigger reconciles.
+	HTTPRouteFilter WatchFilter
+
+	reported reportedProblems
+}
+
+// ingressRoute is the HTTPRoute generated for one host of an Ingress. Rules
+// without a host and the default backend make up the route without hostname.
+type ingressRoute struct {
+	hostname string
+	rules    []gatewayv1.HTTPRouteRule
+}
+
+// addMatch routes the requests matching match to backendRef, in the rule
+// already sending traffic to backendRef if there is one.
+func (r *ingressRoute) addMatch(match gatewayv1.HTTPRouteMatch, backendRef gatewayv1.HTTPBackendRef) {
+	for i := range r.rules {
+		if equality.Semantic.DeepEqual(r.rules[i].BackendRefs[0], backendRef) {
+			r.rules[i].Matches = append(r.rules[i].Matches, match)
+			return
+		}
+	}
+	r.rules = append(r.rules, gatewayv1.HTTPRouteRule{
+		Matches:     []gatewayv1.HTTPRouteMatch{match},
+		BackendRefs: []gatewayv1.HTTPBackendRef{backendRef},
+	})
+}
+
+// ingressProblem is a feature of an Ingress that the generated HTTPRoutes do
+// not carry over.
+type ingressProblem struct {
+	reason  string
+	message string
+}
+
+// reportedProblems remembers the problems last reported for each migrated
+// Ingress. Ingresses are reconciled on every change of their HTTPRoutes and
+// Services, so Warning Events are only recorded when the problems change,
+// like those of Myapps. They are reported again after a restart.
+type reportedProblems struct {
+	mu       sync.Mutex
+	problems map[types.NamespacedName][]ingressProblem
+}
+
+// changed records the problems of an Ingress and reports whether they differ
+// from those previously recorded.
+func (p *reportedProblems) changed(key types.NamespacedName, problems []ingressProblem) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if slices.Equal(p.problems[key], problems) {
+		return false
+	}
+	if p.problems == nil {
+		p.problems = make(map[types.NamespacedName][]ingressProblem)
+	}
+	p.problems[key] = problems
+	return true
+}
+
+// forget drops the problems of an Ingress that is gone or no longer migrated.
+func (p *reportedProblems) forget(key types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.problems, key)
+}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+
+// Reconcile generates the HTTPRoutes of an Ingress carrying the migration
+// annotation and records an Event for each feature of the Ingress they do not
+// carry over. The HTTPRoutes are deleted with the Ingress, by their owner
+// reference, or when the annotation is removed.
+func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	var ingress networkingv1.Ingress
+	if err := r.Get(ctx, req.NamespacedName, &ingress); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.reported.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	value, migrate := ingress.Annotations[migrateAnnotation]
+	if !migrate || !ingress.DeletionTimestamp.IsZero() {
+		r.reported.forget(req.NamespacedName)
+		return ctrl.Result{}, r.deleteStaleRoutes(ctx, &ingress, nil)
+	}
+
+	gateway, err := r.gatewayFor(&ingress, value)
+	if err != nil {
+		r.reportProblems(&ingress, []ingressProblem{{reasonInvalidGateway, err.Error()}})
+		return ctrl.Result{}, nil
+	}
+
+	var serviceList corev1.ServiceList
+	if err := r.List(ctx, &serviceList, client.InNamespace(ingress.Namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list Services: %w", err)
+	}
+	services := readFilteredServices(ctx, r.APIReader, r.ServiceFilter, ingressServiceKeys(&ingress), serviceList.Items)
+
+	routes, problems := translateIngress(&ingress, services)
+	keep := make(map[string]bool, len(routes))
+	var generated []client.Object
+	for i := range routes {
+		route := &gatewayv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{
+			Name:      ingressRouteName(&ingress, routes[i].hostname),
+			Namespace: ingress.Namespace,
+		}}
+		keep[route.Name] = true
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, route, func() error {
+			if route.ResourceVersion != "" && !metav1.IsControlledBy(route, &ingress) {
+				return errRouteConflict
+			}
+			mutateIngressRoute(&ingress, gateway, routes[i], route)
+			return controllerutil.SetControllerReference(&ingress, route, r.Scheme)
+		})
+		if errors.Is(err, errRouteConflict) {
+			problems = append(problems, ingressProblem{reasonRouteConflict, fmt.Sprintf(
+				"HTTPRoute %s already exists and was not generated for the Ingress, it is left untouched", route.Name)})
+			continue
+		}
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to reconcile HTTPRoute %s/%s: %w", route.Namespace, route.Name, err)
+		}
+		logger.Info("Reconciled HTTPRoute", "name", route.Name, "operation", op)
+		if op != controllerutil.OperationResultNone {
+			r.recordEvent(&ingress, corev1.EventTypeNormal, reasonMigrated,
+				fmt.Sprintf("HTTPRoute %s %s, attached to Gateway %s", route.Name, op, gateway))
+		}
+		generated = append(generated, route)
+	}
+	if err := r.deleteStaleRoutes(ctx, &ingress, keep); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, ref := range checkBackendRefs(generated, services, nil) {
+		problems = append(problems, ingressProblem{ref.reason, fmt.Sprintf("HTTPRoute %s: %s", ref.route.Name, ref.message)})
+	}
+	problems = append(problems, r.tlsProblems(ctx, &ingress, gateway)...)
+	r.reportProblems(&ingress, problems)
+	return ctrl.Result{}, nil
+}
+
+// reportProblems records a Warning Event for each problem of an Ingress when
+// they changed since they were last reported.
+func (r *IngressReconciler) reportProblems(ingress *networkingv1.Ingress, problems []ingressProblem) {
+	if !r.reported.changed(client.ObjectKeyFromObject(ingress), problems) {
+		return
+	}
+	for _, problem := range problems {
+		r.recordEvent(ingress, corev1.EventTypeWarning, problem.reason, problem.message)
+	}
+}
+
+// gatewayFor returns the Gateway named by the migration annotation of an
+// Ingress, or the default Gateway when the annotation is empty.
+func (r *IngressReconciler) gatewayFor(ingress *networkingv1.Ingress, value string) (types.NamespacedName, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if r.DefaultGateway == nil {
+			return types.NamespacedName{}, fmt.Errorf(
+				"annotation %s names no Gateway and the manager has no default Gateway", migrateAnnotation)
+		}
+		return *r.DefaultGateway, nil
+	}
+
+	namespace, name, found := strings.Cut(value, "/")
+	if !found {
+		namespace, name = ingress.Namespace, value
+	}
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return types.NamespacedName{}, fmt.Errorf(
+			"invalid Gateway %q in annotation %s: expected namespace/name or name", value, migrateAnnotation)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// tlsProblems checks that the Gateway terminates TLS for the hosts of each TLS
+// section of the Ingress, with the same Secret. TLS is configured on Gateway
+// listeners rather than on routes, so it is not migrated along with the
+// HTTPRoutes.
+func (r *IngressReconciler) tlsProblems(
+	ctx context.Context, ingress *networkingv1.Ingress, key types.NamespacedName,
+) []ingressProblem {
+	var gateway gatewayv1.Gateway
+	if err := r.Get(ctx, key, &gateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []ingressProblem{{reasonGatewayNotFound, fmt.Sprintf("Gateway %s does not exist", key)}}
+		}
+		logf.FromContext(ctx).Info("Failed to get Gateway, not checking TLS", "gateway", key, "error", err)
+		return nil
+	}
+
+	var problems []ingressProblem
+	for _, tls := range ingress.Spec.TLS {
+		hosts := tls.Hosts
+		if len(hosts) == 0 {
+			hosts = []string{""}
+		}
+		for _, host := range hosts {
+			if gatewayTerminatesTLS(&gateway, host, ingress.Namespace, tls.SecretName) {
+				continue
+			}
+			target := "any host"
+			if host != "" {
+				target = host
+			}
+			secret := "its default certificate"
+			if tls.SecretName != "" {
+				secret = fmt.Sprintf("Secret %s/%s", ingress.Namespace, tls.SecretName)
+			}
+			problems = append(problems, ingressProblem{reasonTLSNotMigrated, fmt.Sprintf(
+				"Gateway %s has no HTTPS listener for %s using %s, TLS is configured on Gateway listeners",
+				key, target, secret)})
+		}
+	}
+	return problems
+}
+
+// gatewayTerminatesTLS reports whether a listener of the Gateway terminates
+// HTTPS for host with the given Secret, or with any certificate when
+// secretName is empty.
+func gatewayTerminatesTLS(gateway *gatewayv1.Gateway, host, namespace, secretName string) bool {
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Protocol != gatewayv1.HTTPSProtocolType || listener.TLS == nil {
+			continue
+		}
+		if host != "" && !listenerMatchesHostnames(listener, []gatewayv1.Hostname{gatewayv1.Hostname(host)}) {
+			continue
+		}
+		if secretName == "" {
+			return true
+		}
+		for _, ref := range listener.TLS.CertificateRefs {
+			if ref.Group != nil && *ref.Group != "" || ref.Kind != nil && *ref.Kind != "Secret" {
+				continue
+			}
+			refNamespace := gateway.Namespace
+			if ref.Namespace != nil {
+				refNamespace = string(*ref.Namespace)
+			}
+			if refNamespace == namespace && string(ref.Name) == secretName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteStaleRoutes deletes the HTTPRoutes generated for the Ingress whose
+// names are not in keep.
+func (r *IngressReconciler) deleteStaleRoutes(ctx context.Context, ingress *networkingv1.Ingress, keep map[string]bool) error {
+	var routes gatewayv1.HTTPRouteList
+	if err := r.List(ctx, &routes, client.InNamespace(ingress.Namespace)); err != nil {
+		return fmt.Errorf("failed to list HTTPRoutes: %w", err)
+	}
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		if keep[route.Name] || !metav1.IsControlledBy(route, ingress) {
+			continue
+		}
+		if err := r.Delete(ctx, route); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete HTTPRoute %s/%s: %w", route.Namespace, route.Name, err)
+		}
+		logf.FromContext(ctx).Info("Deleted HTTPRoute", "name", route.Name)
+	}
+	return nil
+}
+
+// recordEvent records an Event on the Ingress.
+func (r *IngressReconciler) recordEvent(ingress *networkingv1.Ingress, eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(ingress, eventType, reason, message)
+}
+
+// translateIngress returns the HTTPRoutes equivalent to an Ingress, one per
+// host, along with the features of the Ingress they do not carry over. Named
+// Service ports are resolved against the given Services.
+func translateIngress(ingress *networkingv1.Ingress, services []corev1.Service) ([]ingressRoute, []ingressProblem) {
+	var problems []ingressProblem
+	for _, name := range ingressControllerAnnotations(ingress) {
+		problems = append(problems, ingressProblem{reasonUnsupportedAnnotation, fmt.Sprintf(
+			"annotation %s configures the ingress controller and has no HTTPRoute equivalent", name)})
+	}
+
+	var routes []*ingressRoute
+	routeFor := func(hostname string) *ingressRoute {
+		for _, route := range routes {
+			if route.hostname == hostname {
+				return route
+			}
+		}
+		route := &ingressRoute{hostname: hostname}
+		routes = append(routes, route)
+		return route
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			match, problem := ingressPathMatch(rule.Host, path)
+			if problem != nil {
+				problems = append(problems, *problem)
+				if match == nil {
+					continue
+				}
+			}
+			backendRef, problem := ingressBackendRef(ingress.Namespace, path.Backend, services)
+			if problem != nil {
+				problems = append(problems, *problem)
+				continue
+			}
+			routeFor(rule.Host).addMatch(*match, *backendRef)
+		}
+	}
+	if backend := ingress.Spec.DefaultBackend; backend != nil {
+		backendRef, problem := ingressBackendRef(ingress.Namespace, *backend, services)
+		if problem != nil {
+			problems = append(problems, *problem)
+		} else {
+			routeFor("").addMatch(pathPrefixMatch("/"), *backendRef)
+		}
+	}
+
+	translated := make([]ingressRoute, 0, len(routes))
+	for _, route := range routes {
+		if len(route.rules) > maxRouteRules {
+			problems = append(problems, ingressProblem{reasonTooManyRules, fmt.Sprintf(
+				"host %q sends traffic to %d backends, only the first %d are migrated",
+				route.hostname, len(route.rules), maxRouteRules)})
+			route.rules = route.rules[:maxRouteRules]
+		}
+		translated = append(translated, *route)
+	}
+	return translated, problems
+}
+
+// ingressControllerAnnotations returns the sorted annotations of an Ingress
+// that configure ingress controllers, e.g. nginx.ingress.kubernetes.io/rewrite-target.
+func ingressControllerAnnotations(ingress *networkingv1.Ingress) []string {
+	var names []string
+	for name := range ingress.Annotations {
+		prefix, _, found := strings.Cut(name, "/")
+		if found && (prefix == "ingress.kubernetes.io" || strings.HasSuffix(prefix, ".ingress.kubernetes.io")) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// pathPrefixMatch returns a match of the requests whose path starts with prefix.
+func pathPrefixMatch(prefix string) gatewayv1.HTTPRouteMatch {
+	return gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{
+		Type:  ptr.To(gatewayv1.PathMatchPathPrefix),
+		Value: ptr.To(prefix),
+	}}
+}
+
+// ingressPathMatch translates an Ingress path to an HTTPRoute match. Paths of
+// the ImplementationSpecific type are translated to prefix matches and
+// reported; paths that are not absolute are reported and not translated.
+func ingressPathMatch(host string, path networkingv1.HTTPIngressPath) (*gatewayv1.HTTPRouteMatch, *ingressProblem) {
+	value := path.Path
+	if value == "" {
+		value = "/"
+	}
+	if !strings.HasPrefix(value, "/") {
+		return nil, &ingressProblem{reasonInvalidPath, fmt.Sprintf(
+			"path %q of host %q is not absolute and is not migrated", value, host)}
+	}
+
+	pathType := networkingv1.PathTypeImplementationSpecific
+	if path.PathType != nil {
+		pathType = *path.PathType
+	}
+	match := pathPrefixMatch(value)
+	switch pathType {
+	case networkingv1.PathTypeExact:
+		match.Path.Type = ptr.To(gatewayv1.PathMatchExact)
+	case networkingv1.PathTypePrefix:
+	default:
+		return &match, &ingressProblem{reasonImplementationSpecificPath, fmt.Sprintf(
+			"path %q of host %q has type %s and is migrated as a PathPrefix match, "+
+				"regular expressions are not supported", value, host, pathType)}
+	}
+	return &match, nil
+}
+
+// ingressBackendRef translates an Ingress backend to an HTTPRoute backendRef,
+// resolving named ports against the given Services. Resource backends and
+// named ports that do not resolve are reported.
+func ingressBackendRef(
+	namespace string, backend networkingv1.IngressBackend, services []corev1.Service,
+) (*gatewayv1.HTTPBackendRef, *ingressProblem) {
+	if backend.Service == nil {
+		kind := "resource"
+		if backend.Resource != nil {
+			kind = fmt.Sprintf("%s %s", backend.Resource.Kind, backend.Resource.Name)
+		}
+		return nil, &ingressProblem{reasonResourceBackend, fmt.Sprintf(
+			"backend %s is not a Service and is not migrated", kind)}
+	}
+
+	port := backend.Service.Port.Number
+	if name := backend.Service.Port.Name; name != "" {
+		key := types.NamespacedName{Namespace: namespace, Name: backend.Service.Name}
+		i := slices.IndexFunc(services, func(service corev1.Service) bool {
+			return client.ObjectKeyFromObject(&service) == key
+		})
+		if i < 0 {
+			return nil, &ingressProblem{reasonUnresolvedPort, fmt.Sprintf(
+				"Service %s does not exist, its port %s cannot be resolved", key, name)}
+		}
+		for _, servicePort := range services[i].Spec.Ports {
+			if servicePort.Name == name {
+				port = servicePort.Port
+			}
+		}
+		if port == 0 {
+			return nil, &ingressProblem{reasonUnresolvedPort, fmt.Sprintf(
+				"Service %s has no port named %s", key, name)}
+		}
+	}
+
+	return &gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{
+			Group: ptr.To(gatewayv1.Group("")),
+			Kind:  ptr.To(gatewayv1.Kind("Service")),
+			Name:  gatewayv1.ObjectName(backend.Service.Name),
+			Port:  ptr.To(gatewayv1.PortNumber(port)),
+		},
+		Weight: ptr.To[int32](1),
+	}}, nil
+}
+
+// ingressServiceKeys returns the Services the backends of an Ingress point at.
+func ingressServiceKeys(ingress *networkingv1.Ingress) []types.NamespacedName {
+	var backends []networkingv1.IngressBackend
+	if ingress.Spec.DefaultBackend != nil {
+		backends = append(backends, *ingress.Spec.DefaultBackend)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, path.Backend)
+		}
+	}
+
+	var keys []types.NamespacedName
+	for _, backend := range backends {
+		if backend.Service != nil {
+			keys = append(keys, types.NamespacedName{Namespace: ingress.Namespace, Name: backend.Service.Name})
+		}
+	}
+	return keys
+}
+
+// ingressRouteName returns the name of the HTTPRoute generated for a host of
+// an Ingress: the Ingress name followed by the host, with "wildcard" in place
+// of a leading "*", or the Ingress name for the route without hostname. The
+// names of the routes of other hosts do not change when hosts are added or
+// removed. Names too long for an object are cut and end with a hash of the host.
+func ingressRouteName(ingress *networkingv1.Ingress, hostname string) string {
+	if hostname == "" {
+		return ingress.Name
+	}
+	name := ingress.Name + "-" + strings.Replace(hostname, "*", "wildcard", 1)
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(hostname))
+	prefix := strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-9], "-.")
+	return fmt.Sprintf("%s-%08x", prefix, hash.Sum32())
+}
+
+// mutateIngressRoute sets the fields of an HTTPRoute generated for an Ingress.
+// Like for the HTTPRoutes of Myapps, values the CRD would default are set
+// explicitly.
+func mutateIngressRoute(
+	ingress *networkingv1.Ingress, gateway types.NamespacedName, translated ingressRoute, route *gatewayv1.HTTPRoute,
+) {
+	route.Labels = mergeLabels(route.Labels, map[string]string{
+		managedByLabel: managedByValue,
+		managedLabel:   "true",
+	})
+
+	parentRef := gatewayv1.ParentReference{
+		Group: ptr.To(gatewayv1.Group(gatewayv1.GroupName)),
+		Kind:  ptr.To(gatewayv1.Kind("Gateway")),
+		Name:  gatewayv1.ObjectName(gateway.Name),
+	}
+	if gateway.Namespace != ingress.Namespace {
+		parentRef.Namespace = ptr.To(gatewayv1.Namespace(gateway.Namespace))
+	}
+	route.Spec.ParentRefs = []gatewayv1.ParentReference{parentRef}
+
+	route.Spec.Hostnames = nil
+	if translated.hostname != "" {
+		route.Spec.Hostnames = []gatewayv1.Hostname{gatewayv1.Hostname(translated.hostname)}
+	}
+	route.Spec.Rules = translated.rules
+}
+
+// hasMigrateAnnotation reports whether the object carries the migration annotation.
+func hasMigrateAnnotation(obj client.Object) bool {
+	_, found := obj.GetAnnotations()[migrateAnnotation]
+	return found
+}
+
+// migrationRequested lets through the events of Ingresses carrying the
+// migration annotation, and the updates removing it so that their HTTPRoutes
+// are deleted. Deleted Ingresses are cleaned up by owner references.
+var migrationRequested = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return hasMigrateAnnotation(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return hasMigrateAnnotation(e.ObjectOld) || hasMigrateAnnotation(e.ObjectNew)
+	},
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(e event.GenericEvent) bool { return hasMigrateAnnotation(e.Object) },
+}
+
+// ingressesForService maps a Service event to the migrated Ingresses of its
+// namespace that send traffic to it, whose named ports may now resolve differently.
+func (r *IngressReconciler) ingressesForService(ctx context.Context, service client.Object) []reconcile.Request {
+	var ingresses networkingv1.IngressList
+	if err := r.List(ctx, &ingresses, client.InNamespace(service.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Info("Failed to list Ingresses", "error", err)
+		return nil
+	}
+
+	var requests requestSet
+	serviceKey := client.ObjectKeyFromObject(service)
+	for i := range ingresses.Items {
+		ingress := &ingresses.Items[i]
+		if hasMigrateAnnotation(ingress) && slices.Contains(ingressServiceKeys(ingress), serviceKey) {
+			requests.add(client.ObjectKeyFromObject(ingress))
+		}
+	}
+	return requests.requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1.Ingress{}, builder.WithPredicates(migrationRequested)).
+		Owns(&gatewayv1.HTTPRoute{}, builder.WithPredicates(r.HTTPRouteFilter.predicate())).
+		Watches(&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesForService),
+			builder.WithPredicates(r.ServiceFilter.predicate())).
+		Complete(r)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayapischeme "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/scheme"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+var _ = Describe("Ingress Controller", func() {
+	Context("When migrating an Ingress to HTTPRoutes", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *IngressReconciler
+			recorder   *record.FakeRecorder
+			ingress    *networkingv1.Ingress
+			gateway    *gatewayv1.Gateway
+		)
+
+		key := types.NamespacedName{Name: "shop", Namespace: "default"}
+		const (
+			shopRoute     = "shop-shop.my-apps.com"
+			wildcardRoute = "shop-wildcard.shop.my-apps.com"
+		)
+
+		serviceBackend := func(name string, port networkingv1.ServiceBackendPort) networkingv1.IngressBackend {
+			return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: name, Port: port}}
+		}
+
+		ingressPath := func(
+			path string, pathType networkingv1.PathType, backend networkingv1.IngressBackend,
+		) networkingv1.HTTPIngressPath {
+			return networkingv1.HTTPIngressPath{Path: path, PathType: ptr.To(pathType), Backend: backend}
+		}
+
+		newReconciler := func(objs ...client.Object) {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			services := []client.Object{
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: key.Namespace},
+					Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 8080}}},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: key.Namespace},
+					Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "grpc", Port: 9090}}},
+				},
+			}
+			recorder = record.NewFakeRecorder(32)
+			reconciler = &IngressReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).
+					WithObjects(append(services, objs...)...).Build(),
+				Scheme:   scheme,
+				Recorder: recorder,
+			}
+		}
+
+		reconcileIngress := func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		// eventReasons drains the recorded Events and returns their reasons.
+		eventReasons := func() []string {
+			var reasons []string
+			for _, event := range drainEvents(recorder) {
+				reasons = append(reasons, strings.Fields(event)[1])
+			}
+			return reasons
+		}
+
+		getRoute := func(name string) *gatewayv1.HTTPRoute {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: key.Namespace}, &route)).To(Succeed())
+			return &route
+		}
+
+		BeforeEach(func() {
+			ingress = &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "shop-uid",
+					Annotations: map[string]string{
+						migrateAnnotation: "infra/public",
+						"nginx.ingress.kubernetes.io/rewrite-target": "/",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: ptr.To("nginx"),
+					DefaultBackend:   ptr.To(serviceBackend("web", networkingv1.ServiceBackendPort{Name: "http"})),
+					TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.my-apps.com"}, SecretName: "shop-tls"}},
+					Rules: []networkingv1.IngressRule{
+						{
+							Host: "shop.my-apps.com",
+							IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{
+									ingressPath("/api", networkingv1.PathTypePrefix,
+										serviceBackend("api", networkingv1.ServiceBackendPort{Number: 9090})),
+									ingressPath("/", networkingv1.PathTypeExact,
+										serviceBackend("web", networkingv1.ServiceBackendPort{Name: "http"})),
+									ingressPath("/static", networkingv1.PathTypeImplementationSpecific,
+										serviceBackend("web", networkingv1.ServiceBackendPort{Number: 8080})),
+								},
+							}},
+						},
+						{
+							Host: "*.shop.my-apps.com",
+							IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{
+									ingressPath("/", networkingv1.PathTypePrefix,
+										serviceBackend("web", networkingv1.ServiceBackendPort{Number: 8080})),
+								},
+							}},
+						},
+					},
+				},
+			}
+			gateway = &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "infra"},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "istio",
+					Listeners:        []gatewayv1.Listener{{Name: "http", Port: 80, Protocol: gatewayv1.HTTPProtocolType}},
+				},
+			}
+		})
+
+		It("should generate an HTTPRoute per host and report what does not translate", func() {
+			newReconciler(ingress, gateway)
+			reconcileIngress()
+
+			By("routing the paths of each host to their backends")
+			shop := getRoute(shopRoute)
+			Expect(metav1.IsControlledBy(shop, ingress)).To(BeTrue())
+			Expect(shop.Labels).To(HaveKeyWithValue(managedLabel, "true"))
+			Expect(shop.Spec.ParentRefs).To(ConsistOf(HaveField("Namespace", HaveValue(BeEquivalentTo("infra")))))
+			Expect(shop.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("shop.my-apps.com")))
+			// Paths sending traffic to the same backend share a rule
+			Expect(shop.Spec.Rules).To(HaveLen(2))
+			Expect(shop.Spec.Rules[0].Matches).To(ConsistOf(pathPrefixMatch("/api")))
+			Expect(shop.Spec.Rules[0].BackendRefs[0].Name).To(BeEquivalentTo("api"))
+			Expect(shop.Spec.Rules[1].Matches).To(HaveLen(2))
+			Expect(*shop.Spec.Rules[1].Matches[0].Path.Type).To(Equal(gatewayv1.PathMatchExact))
+			Expect(shop.Spec.Rules[1].Matches[1]).To(Equal(pathPrefixMatch("/static")))
+			Expect(shop.Spec.Rules[1].BackendRefs[0].Name).To(BeEquivalentTo("web"))
+			Expect(*shop.Spec.Rules[1].BackendRefs[0].Port).To(BeEquivalentTo(8080), "the named port is resolved")
+
+			Expect(getRoute(wildcardRoute).Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("*.shop.my-apps.com")))
+
+			By("catching the requests of other hosts with the default backend")
+			defaultRoute := getRoute(key.Name)
+			Expect(defaultRoute.Spec.Hostnames).To(BeEmpty())
+			Expect(defaultRoute.Spec.Rules).To(HaveLen(1))
+			Expect(defaultRoute.Spec.Rules[0].Matches).To(ConsistOf(pathPrefixMatch("/")))
+
+			Expect(eventReasons()).To(ConsistOf(
+				reasonMigrated, reasonMigrated, reasonMigrated,
+				reasonUnsupportedAnnotation, reasonImplementationSpecificPath, reasonTLSNotMigrated,
+			))
+
+			By("not reporting TLS once the Gateway terminates it with the Ingress Secret")
+			gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1.Listener{
+				Name:     "https",
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				Hostname: ptr.To(gatewayv1.Hostname("*.my-apps.com")),
+				TLS: &gatewayv1.GatewayTLSConfig{CertificateRefs: []gatewayv1.SecretObjectReference{{
+					Name:      "shop-tls",
+					Namespace: ptr.To(gatewayv1.Namespace(key.Namespace)),
+				}}},
+			})
+			Expect(reconciler.Update(ctx, gateway)).To(Succeed())
+			reconcileIngress()
+			Expect(eventReasons()).To(ConsistOf(reasonUnsupportedAnnotation, reasonImplementationSpecificPath),
+				"unchanged HTTPRoutes are not reported as migrated again")
+
+			By("not reporting the same problems again")
+			reconcileIngress()
+			Expect(eventReasons()).To(BeEmpty())
+
+			By("keeping the names of the HTTPRoutes of the other hosts when a host is removed")
+			Expect(reconciler.Get(ctx, key, ingress)).To(Succeed())
+			ingress.Spec.Rules = ingress.Spec.Rules[1:]
+			Expect(reconciler.Update(ctx, ingress)).To(Succeed())
+			reconcileIngress()
+			Expect(eventReasons()).To(ConsistOf(reasonUnsupportedAnnotation), "the problems changed")
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: shopRoute, Namespace: key.Namespace},
+				&gatewayv1.HTTPRoute{})).To(Satisfy(errors.IsNotFound))
+			Expect(getRoute(wildcardRoute).Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("*.shop.my-apps.com")))
+			Expect(getRoute(key.Name).Spec.Hostnames).To(BeEmpty())
+		})
+
+		It("should report backends that do not resolve", func() {
+			ingress.Spec.TLS = nil
+			ingress.Spec.DefaultBackend = &networkingv1.IngressBackend{Resource: &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To("storage.k8s.io"), Kind: "Bucket", Name: "assets",
+			}}
+			ingress.Spec.Rules[0].HTTP.Paths = []networkingv1.HTTPIngressPath{
+				ingressPath("/api", networkingv1.PathTypePrefix,
+					serviceBackend("api", networkingv1.ServiceBackendPort{Name: "http"})),
+				ingressPath("/", networkingv1.PathTypePrefix,
+					serviceBackend("web", networkingv1.ServiceBackendPort{Number: 80})),
+			}
+			ingress.Spec.Rules = ingress.Spec.Rules[:1]
+			delete(ingress.Annotations, "nginx.ingress.kubernetes.io/rewrite-target")
+			newReconciler(ingress, gateway)
+			reconcileIngress()
+
+			route := getRoute(shopRoute)
+			Expect(route.Spec.Rules).To(HaveLen(1))
+			Expect(route.Spec.Rules[0].Matches).To(ConsistOf(pathPrefixMatch("/")))
+			Expect(eventReasons()).To(ConsistOf(
+				reasonMigrated, reasonUnresolvedPort, reasonResourceBackend, webappv1.BackendReasonPortNotFound,
+			))
+		})
+
+		It("should attach to the default Gateway when the annotation names none", func() {
+			ingress.Annotations[migrateAnnotation] = ""
+			newReconciler(ingress, gateway)
+			reconcileIngress()
+			Expect(eventReasons()).To(ContainElement(reasonInvalidGateway))
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: shopRoute, Namespace: key.Namespace},
+				&gatewayv1.HTTPRoute{})).To(Satisfy(errors.IsNotFound))
+			reconcileIngress()
+			Expect(eventReasons()).To(BeEmpty(), "the missing Gateway is reported once")
+
+			reconciler.DefaultGateway = &types.NamespacedName{Name: "public", Namespace: "infra"}
+			reconcileIngress()
+			Expect(getRoute(shopRoute).Spec.ParentRefs).To(ConsistOf(HaveField("Name", BeEquivalentTo("public"))))
+		})
+
+		It("should leave foreign HTTPRoutes alone and delete its HTTPRoutes when the annotation is removed", func() {
+			foreign := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: wildcardRoute, Namespace: key.Namespace},
+				Spec: gatewayv1.HTTPRouteSpec{
+					Hostnames: []gatewayv1.Hostname{"foreign.my-apps.com"},
+				},
+			}
+			newReconciler(ingress, gateway, foreign)
+			reconcileIngress()
+			Expect(eventReasons()).To(ContainElement(reasonRouteConflict))
+			Expect(getRoute(wildcardRoute).Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("foreign.my-apps.com")))
+
+			Expect(reconciler.Get(ctx, key, ingress)).To(Succeed())
+			delete(ingress.Annotations, migrateAnnotation)
+			Expect(reconciler.Update(ctx, ingress)).To(Succeed())
+			reconcileIngress()
+
+			for _, name := range []string{shopRoute, key.Name} {
+				err := reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: key.Namespace}, &gatewayv1.HTTPRoute{})
+				Expect(errors.IsNotFound(err)).To(BeTrue(), name)
+			}
+			Expect(getRoute(wildcardRoute).Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("foreign.my-apps.com")))
+		})
+	})
+})
@@ -0,0 +1,210 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+var (
+	// brokenBackendRefs counts the backendRefs of each route that point at a
+	// missing Service or at a port the Service does not expose.
+	brokenBackendRefs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_route_broken_backend_refs",
+			Help: "Number of route backendRefs pointing at a missing Service or Service port.",
+		},
+		[]string{"namespace", "kind", "route"},
+	)
+
+	// orphanedBackendRefs counts the backendRefs of the routes of each
+	// namespace that point at a Service that does not exist.
+	orphanedBackendRefs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_orphaned_backend_refs",
+			Help: "Number of route backendRefs pointing at a Service that does not exist.",
+		},
+		[]string{"namespace"},
+	)
+
+	// httpRoutesTotal, grpcRoutesTotal, tcpRoutesTotal, tlsRoutesTotal and
+	// servicesTotal count the routes and Services of each namespace holding a
+	// Myapp, as seen on its last reconcile.
+	httpRoutesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_httproutes",
+			Help: "Number of HTTPRoutes in namespaces holding a Myapp.",
+		},
+		[]string{"namespace"},
+	)
+	grpcRoutesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_grpcroutes",
+			Help: "Number of GRPCRoutes in namespaces holding a Myapp.",
+		},
+		[]string{"namespace"},
+	)
+	tcpRoutesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_tcproutes",
+			Help: "Number of TCPRoutes in namespaces holding a Myapp.",
+		},
+		[]string{"namespace"},
+	)
+	tlsRoutesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_tlsroutes",
+			Help: "Number of TLSRoutes in namespaces holding a Myapp.",
+		},
+		[]string{"namespace"},
+	)
+	servicesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_services",
+			Help: "Number of Services in namespaces holding a Myapp.",
+		},
+		[]string{"namespace"},
+	)
+
+	// myappsByReady counts the Myapps of each namespace by the status of their Ready condition.
+	myappsByReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kontroller_myapps",
+			Help: "Number of Myapps by the status of their Ready condition.",
+		},
+		[]string{"namespace", "ready"},
+	)
+
+	// reconcileDuration observes how long Myapp reconciles take, by the kind of
+	// object whose event triggered them.
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kontroller_reconcile_duration_seconds",
+			Help:    "Duration of Myapp reconciles by the kind of object that triggered them.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"trigger"},
+	)
+)
+
+// routesTotalByKind holds the per route kind metrics.
+var routesTotalByKind = map[string]*prometheus.GaugeVec{
+	"HTTPRoute": httpRoutesTotal,
+	"GRPCRoute": grpcRoutesTotal,
+	"TCPRoute":  tcpRoutesTotal,
+	"TLSRoute":  tlsRoutesTotal,
+}
+
+// namespaceGauges are the metrics with series per namespace.
+var namespaceGauges = []*prometheus.GaugeVec{
+	brokenBackendRefs,
+	orphanedBackendRefs,
+	httpRoutesTotal,
+	grpcRoutesTotal,
+	tcpRoutesTotal,
+	tlsRoutesTotal,
+	servicesTotal,
+	myappsByReady,
+}
+
+func init() {
+	// Register custom metrics with the global prometheus registry served by the manager
+	metrics.Registry.MustRegister(
+		brokenBackendRefs,
+		orphanedBackendRefs,
+		httpRoutesTotal,
+		grpcRoutesTotal,
+		tcpRoutesTotal,
+		tlsRoutesTotal,
+		servicesTotal,
+		myappsByReady,
+		reconcileDuration,
+	)
+}
+
+// recordBrokenBackendRefs replaces the broken backendRef series of a namespace,
+// so that routes which were fixed or deleted report zero or disappear.
+func recordBrokenBackendRefs(namespace string, routes []client.Object, broken []brokenBackendRef) {
+	brokenBackendRefs.DeletePartialMatch(prometheus.Labels{"namespace": namespace})
+	for _, route := range routes {
+		brokenBackendRefs.WithLabelValues(namespace, routeKindOf(route), route.GetName()).Set(0)
+	}
+	orphaned := 0
+	for _, ref := range broken {
+		if ref.route.Namespace != namespace {
+			continue
+		}
+		brokenBackendRefs.WithLabelValues(namespace, ref.kind, ref.route.Name).Inc()
+		if ref.reason == webappv1.BackendReasonServiceNotFound {
+			orphaned++
+		}
+	}
+	orphanedBackendRefs.WithLabelValues(namespace).Set(float64(orphaned))
+}
+
+// recordNamespaceObjects records the number of routes of each kind and of
+// Services of a namespace.
+func recordNamespaceObjects(namespace string, routes []client.Object, services []corev1.Service) {
+	counts := make(map[string]int, len(routesTotalByKind))
+	for _, route := range routes {
+		counts[routeKindOf(route)]++
+	}
+	for kind, gauge := range routesTotalByKind {
+		gauge.WithLabelValues(namespace).Set(float64(counts[kind]))
+	}
+	servicesTotal.WithLabelValues(namespace).Set(float64(len(services)))
+}
+
+// recordMyapps records the number of Myapps of a namespace by the status of
+// their Ready condition. Myapps without the condition count as Unknown. The
+// series of a namespace without Myapps are deleted, as it is no longer reconciled.
+func recordMyapps(namespace string, myapps []webappv1.Myapp) {
+	if len(myapps) == 0 {
+		forgetNamespace(namespace)
+		return
+	}
+	counts := map[metav1.ConditionStatus]int{
+		metav1.ConditionTrue:    0,
+		metav1.ConditionFalse:   0,
+		metav1.ConditionUnknown: 0,
+	}
+	for i := range myapps {
+		status := metav1.ConditionUnknown
+		if ready := meta.FindStatusCondition(myapps[i].Status.Conditions, webappv1.ConditionReady); ready != nil {
+			status = ready.Status
+		}
+		counts[status]++
+	}
+	for status, count := range counts {
+		myappsByReady.WithLabelValues(namespace, string(status)).Set(float64(count))
+	}
+}
+
+// forgetNamespace deletes the series of every per namespace metric of a
+// namespace, so that dashboards do not keep showing its last values.
+func forgetNamespace(namespace string) {
+	for _, gauge := range namespaceGauges {
+		gauge.DeletePartialMatch(prometheus.Labels{"namespace": namespace})
+	}
+}
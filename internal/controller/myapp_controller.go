@@ -18,14 +18,30 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	webappv1 "my-apps.com/myapp/api/v1"
 )
@@ -33,94 +49,577 @@ import (
 // MyappReconciler reconciles a Myapp object
 type MyappReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// APIReader reads objects the cache filters out, such as Services that are
+	// referenced by HTTPRoutes without having opted in to ServiceFilter.
+	APIReader client.Reader
+	// ServiceFilter restricts the Services that are cached and trigger reconciles.
+	ServiceFilter WatchFilter
+	// HTTPRouteFilter restricts the HTTPRoutes that are cached and trigger reconciles.
+	HTTPRouteFilter WatchFilter
+	// GRPCRouteFilter, TCPRouteFilter and TLSRouteFilter restrict the routes of
+	// these kinds that are cached and trigger reconciles.
+	GRPCRouteFilter WatchFilter
+	TCPRouteFilter  WatchFilter
+	TLSRouteFilter  WatchFilter
+
+	// triggers remembers the kind of object whose event queued each request.
+	triggers triggerKinds
 }
 
 // +kubebuilder:rbac:groups=webapp.my-apps.com,resources=myapps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=webapp.my-apps.com,resources=myapps/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=webapp.my-apps.com,resources=myapps/finalizers,verbs=update
-// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=grpcroutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tcproutes;tlsroutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways;gatewayclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Myapp object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// For a Myapp it creates or updates the owned Deployment and Service so that
+// they match the workload described by the Myapp spec, and a route of the kind
+// selected by its protocol when the Myapp has a routing section. With a canary
+// rollout, a new Pod template runs in a canary Deployment first, and the route
+// weights shift traffic to it step by step. The backendRefs of the routes
+// in its namespace are checked against existing Services and ReferenceGrants, and their parentRefs
+// against the listeners of the referenced Gateways. The outcome is reported
+// through the Myapp status conditions. On deletion, a finalizer holds the Myapp
+// until every generated object, in any namespace, is deleted.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
 func (r *MyappReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := logf.FromContext(ctx)
 
-	logger.Info("\n\n------------------------- Reconciling the resource -------------------------\n")
+	logger.Info("Reconciling Myapp")
+
+	start := time.Now()
+	trigger := r.triggers.take(req.NamespacedName)
+	defer func() {
+		reconcileDuration.WithLabelValues(trigger).Observe(time.Since(start).Seconds())
+	}()
+	defer r.recordMyapps(ctx, req.Namespace)
+
+	var myapp webappv1.Myapp
+	if err := r.Get(ctx, req.NamespacedName, &myapp); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			logger.Info("Resource MyApp not found in RequestNamespace. Ignoring since object must be deleted", "namespacedName", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get resource")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Myapp event detected", "name", myapp.Name, "namespace", myapp.Namespace)
+
+	return r.reconcileMyapp(ctx, &myapp)
+}
+
+// reconcileMyapp reconciles the objects generated for the Myapp and reports
+// the outcome in its status.
+func (r *MyappReconciler) reconcileMyapp(ctx context.Context, myapp *webappv1.Myapp) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	if !myapp.DeletionTimestamp.IsZero() {
+		logger.Info("Myapp is being deleted, cleaning up generated objects")
+		return r.finalize(ctx, myapp)
+	}
+
+	if controllerutil.AddFinalizer(myapp, myappFinalizer) {
+		if err := r.Update(ctx, myapp); err != nil {
+			logger.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	original := myapp.DeepCopy()
+	// Drift is reported against the current spec only
+	if myapp.Generation != myapp.Status.ObservedGeneration {
+		myapp.Status.Drift = nil
+	}
+
+	canary, err := r.reconcileCanary(ctx, myapp)
+	var conflict *objectConflictError
+	if errors.As(err, &conflict) {
+		return r.retryConflict(ctx, original, myapp, conflict)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile Myapp canary")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+	blueGreen, err := r.reconcileBlueGreen(ctx, myapp)
+	if errors.As(err, &conflict) {
+		return r.retryConflict(ctx, original, myapp, conflict)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile Myapp colors")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+
+	deployment, service, err := r.reconcileWorkload(ctx, myapp, canary.holdStable || blueGreen.holdStable, blueGreen)
+	if errors.As(err, &conflict) {
+		return r.retryConflict(ctx, original, myapp, conflict)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile Myapp workload")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+
+	route, err := r.reconcileRoute(ctx, myapp)
+	if meta.IsNoMatchError(err) {
+		// Retrying will not help until the route CRDs are installed
+		logger.Info("Route kind is not installed", "error", err)
+		setRouteKindMissingConditions(myapp, routeKindOf(newRoute(protocolFor(myapp.Spec.Routing))))
+		// The Myapp is retried every minute, only report the missing kind once
+		if ready := meta.FindStatusCondition(original.Status.Conditions, webappv1.ConditionReady); ready == nil ||
+			ready.Reason != webappv1.ReasonRouteKindNotInstalled {
+			r.eventf(myapp, corev1.EventTypeWarning, webappv1.ReasonRouteKindNotInstalled, "%s",
+				meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionReady).Message)
+		}
+		if err := r.updateStatus(ctx, original, myapp); err != nil {
+			logger.Error(err, "Failed to update Myapp status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: missingRouteKindRequeueInterval}, nil
+	}
+	if errors.As(err, &conflict) {
+		return r.retryConflict(ctx, original, myapp, conflict)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile Myapp route")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+
+	if err := r.reconcilePreviewRoute(ctx, myapp); errors.As(err, &conflict) {
+		return r.retryConflict(ctx, original, myapp, conflict)
+	} else if err != nil {
+		logger.Error(err, "Failed to reconcile Myapp preview route")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+
+	// The canary and colors are only deleted once the route no longer sends
+	// traffic to them
+	services := append([]corev1.Service{*service}, blueGreen.services...)
+	if canary.service != nil {
+		services = append(services, *canary.service)
+	} else if err := r.deleteCanary(ctx, myapp); err != nil {
+		logger.Error(err, "Failed to delete Myapp canary")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+	if myapp.Status.BlueGreen == nil {
+		if err := r.deleteColors(ctx, myapp); err != nil {
+			logger.Error(err, "Failed to delete Myapp colors")
+			return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+		}
+	}
+
+	setWorkloadStatus(myapp, deployment, service)
+	setConditions(myapp, deployment, route)
+	setCanaryConditions(myapp)
+	setBlueGreenConditions(myapp)
+	r.checkHandManagedFields(original, myapp, deployment, service, route)
+	r.checkRoutes(ctx, original, myapp, services, route)
+	if err := r.updateStatus(ctx, original, myapp); err != nil {
+		logger.Error(err, "Failed to update Myapp status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: earliest(canary.requeueAfter, blueGreen.requeueAfter)}, nil
+}
+
+// earliest returns the shortest of the given requeue intervals, ignoring zero
+// ones, or zero when all of them are zero.
+func earliest(intervals ...time.Duration) time.Duration {
+	var shortest time.Duration
+	for _, interval := range intervals {
+		if interval > 0 && (shortest == 0 || interval < shortest) {
+			shortest = interval
+		}
+	}
+	return shortest
+}
+
+// reportFailure records a reconcile error in the Myapp status and as a
+// Warning Event, and returns it.
+func (r *MyappReconciler) reportFailure(ctx context.Context, original, myapp *webappv1.Myapp, err error) error {
+	setFailedConditions(myapp, err)
+	r.eventf(myapp, corev1.EventTypeWarning, reasonReconcileFailed, "%v", err)
+	if statusErr := r.updateStatus(ctx, original, myapp); statusErr != nil {
+		logf.FromContext(ctx).Error(statusErr, "Failed to update Myapp status")
+	}
+	return err
+}
+
+// reportConflict records in the Myapp status, and as a Warning Event, that an
+// object it generates already exists and is not controlled by it.
+func (r *MyappReconciler) reportConflict(
+	ctx context.Context, original, myapp *webappv1.Myapp, conflict *objectConflictError,
+) error {
+	message := conflict.Error()
+	setCondition(myapp, webappv1.ConditionDegraded, metav1.ConditionTrue, webappv1.ReasonObjectConflict, message)
+	setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonObjectConflict, message)
+	// The Myapp is retried every minute, only report the conflict once
+	if degraded := meta.FindStatusCondition(original.Status.Conditions, webappv1.ConditionDegraded); degraded == nil ||
+		degraded.Reason != webappv1.ReasonObjectConflict || degraded.Message != message {
+		r.eventf(myapp, corev1.EventTypeWarning, webappv1.ReasonObjectConflict, "%s", message)
+	}
+	return r.updateStatus(ctx, original, myapp)
+}
+
+// retryConflict reports an object the Myapp does not control. Such objects
+// are not watched, so the Myapp is retried until the object is deleted.
+func (r *MyappReconciler) retryConflict(
+	ctx context.Context, original, myapp *webappv1.Myapp, conflict *objectConflictError,
+) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	logger.Info("Object is not controlled by the Myapp", "error", conflict)
+	if err := r.reportConflict(ctx, original, myapp, conflict); err != nil {
+		logger.Error(err, "Failed to update Myapp status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: conflictRequeueInterval}, nil
+}
+
+// updateStatus writes the Myapp status through the status subresource when it
+// differs from the status the Myapp was read with.
+func (r *MyappReconciler) updateStatus(ctx context.Context, original, myapp *webappv1.Myapp) error {
+	myapp.Status.ObservedGeneration = myapp.Generation
+	if equality.Semantic.DeepEqual(original.Status, myapp.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, myapp)
+}
+
+// conflictRequeueInterval is how often a Myapp whose generated objects exist
+// without being controlled by it is reconciled again.
+const conflictRequeueInterval = time.Minute
+
+// objectConflictError is returned when an object generated for a Myapp
+// already exists and was not generated for it.
+type objectConflictError struct {
+	kind string
+	key  client.ObjectKey
+}
+
+func (e *objectConflictError) Error() string {
+	return fmt.Sprintf("%s %s already exists and was not generated for the Myapp, it is left untouched", e.kind, e.key)
+}
+
+// checkControlled returns an objectConflictError when obj, as read before
+// being mutated, exists and is not controlled by the Myapp, so that objects
+// created by other actors are never taken over.
+func (r *MyappReconciler) checkControlled(myapp *webappv1.Myapp, obj client.Object) error {
+	if obj.GetResourceVersion() == "" || metav1.IsControlledBy(obj, myapp) {
+		return nil
+	}
+	return &objectConflictError{kind: r.kindOf(obj), key: client.ObjectKeyFromObject(obj)}
+}
+
+// reconcileWorkload creates or updates the Deployment and Service owned by the
+// Myapp. Existing objects that are not controlled by the Myapp are left
+// untouched. With holdStable, the Pod template of an existing Deployment is
+// kept, for instance while a canary runs the new one. During a blue/green
+// rollout, the Deployment of the active color replaces the generated one and
+// the Myapp Service selects its Pods.
+func (r *MyappReconciler) reconcileWorkload(
+	ctx context.Context, myapp *webappv1.Myapp, holdStable bool, blueGreen blueGreenRollout,
+) (*appsv1.Deployment, *corev1.Service, error) {
+	logger := logf.FromContext(ctx)
+
+	deployment := blueGreen.active
+	if deployment == nil {
+		revision, err := podRevision(myapp)
+		if err != nil {
+			return nil, nil, err
+		}
+		deployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: myapp.Name, Namespace: myapp.Namespace},
+		}
+		var drifted []string
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+			if err := r.checkControlled(myapp, deployment); err != nil {
+				return err
+			}
+			live := deployment.DeepCopy()
+			mutateDeployment(myapp, deployment)
+			if holdStable && deployment.ResourceVersion != "" {
+				deployment.Spec.Template = *live.Spec.Template.DeepCopy()
+			} else {
+				metav1.SetMetaDataAnnotation(&deployment.ObjectMeta, revisionAnnotation, revision)
+			}
+			drifted = r.revertDrift(live, deployment, deploymentFields)
+			return controllerutil.SetControllerReference(myapp, deployment, r.Scheme)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to reconcile Deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+		}
+		logger.Info("Reconciled Deployment", "name", deployment.Name, "operation", op)
+		r.recordWrite(myapp, deployment, op)
+		r.recordDrift(myapp, deployment, drifted)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: myapp.Name, Namespace: myapp.Namespace},
+	}
+	var drifted []string
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		if err := r.checkControlled(myapp, service); err != nil {
+			return err
+		}
+		live := service.DeepCopy()
+		mutateService(myapp, service)
+		if blueGreen.selector != nil {
+			service.Spec.Selector = blueGreen.selector
+		}
+		drifted = r.revertDrift(live, service, serviceFields)
+		return controllerutil.SetControllerReference(myapp, service, r.Scheme)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reconcile Service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	logger.Info("Reconciled Service", "name", service.Name, "operation", op)
+	r.recordWrite(myapp, service, op)
+	r.recordDrift(myapp, service, drifted)
+
+	return deployment, service, nil
+}
+
+// checkRoutes checks the backendRefs and parentRefs of the routes in the Myapp
+// namespace. written and route are the objects just written for the Myapp,
+// which the cache may not reflect yet.
+func (r *MyappReconciler) checkRoutes(
+	ctx context.Context, original, myapp *webappv1.Myapp, written []corev1.Service, route client.Object,
+) {
+	routes, services, ok := r.listNamespace(ctx, myapp.Namespace)
+	if !ok {
+		return
+	}
+	for _, service := range written {
+		services = upsertObject(services, service)
+	}
+	if route != nil {
+		routes = upsertRoute(routes, route)
+	}
+	r.checkBackends(ctx, original, myapp, routes, services)
+	r.checkParents(ctx, original, myapp, routes)
+}
+
+// checkBackends cross-references the backendRefs of the given routes against
+// the given Services and reports the broken ones relevant to the Myapp in its
+// status and as Events.
+func (r *MyappReconciler) checkBackends(
+	ctx context.Context, original, myapp *webappv1.Myapp, routes []client.Object, services []corev1.Service,
+) {
+	services = r.readFilteredServices(ctx, routes, services)
+	services = r.readCrossNamespaceServices(ctx, routes, services)
+
+	recordNamespaceObjects(myapp.Namespace, routes, services)
+	broken := checkBackendRefs(routes, services, r.listReferenceGrants(ctx, routes))
+	recordBrokenBackendRefs(myapp.Namespace, routes, broken)
+
+	relevant := brokenBackendRefsFor(myapp, routes, broken)
+	statuses := make([]webappv1.BrokenBackendRef, 0, len(relevant))
+	for _, ref := range relevant {
+		statuses = append(statuses, ref.toStatus())
+	}
+	myapp.Status.BrokenBackendRefs = statuses
+
+	if len(relevant) == 0 {
+		setCondition(myapp, webappv1.ConditionBackendRefsResolved, metav1.ConditionTrue, webappv1.ReasonResolved,
+			"All backendRefs resolve to a Service port")
+		return
+	}
+	setCondition(myapp, webappv1.ConditionBackendRefsResolved, metav1.ConditionFalse, webappv1.ReasonBrokenBackendRefs,
+		fmt.Sprintf("%d backendRefs cannot be resolved", len(relevant)))
+
+	// Only emit Events when the set of broken backendRefs changed, the status
+	// keeps the current state for later reconciles
+	if r.Recorder == nil || equality.Semantic.DeepEqual(original.Status.BrokenBackendRefs, myapp.Status.BrokenBackendRefs) {
+		return
+	}
+	for i := range relevant {
+		ref := relevant[i]
+		r.Recorder.Eventf(myapp, corev1.EventTypeWarning, ref.reason, "%s %s rule %d: %s",
+			ref.kind, ref.route, ref.rule, ref.message)
+		for _, route := range routes {
+			if routeKindOf(route) == ref.kind && client.ObjectKeyFromObject(route) == ref.route {
+				r.Recorder.Eventf(route, corev1.EventTypeWarning, ref.reason, "Rule %d: %s", ref.rule, ref.message)
+			}
+		}
+		// The Service exists when its port or the ReferenceGrant is missing
+		for j := range services {
+			if client.ObjectKeyFromObject(&services[j]) == ref.service {
+				r.Recorder.Eventf(&services[j], corev1.EventTypeWarning, ref.reason, "%s %s rule %d: %s",
+					ref.kind, ref.route, ref.rule, ref.message)
+			}
+		}
+	}
+}
+
+// checkParents checks the parentRefs of the routes relevant to the Myapp
+// against the Gateways they reference and reports the broken ones in its
+// status and as Events.
+func (r *MyappReconciler) checkParents(ctx context.Context, original, myapp *webappv1.Myapp, routes []client.Object) {
+	routes = routesFor(myapp, routes)
+	objs := r.readGateways(ctx, routes)
+	broken := checkParentRefs(routes, objs)
+
+	statuses := make([]webappv1.BrokenParentRef, 0, len(broken))
+	for _, ref := range broken {
+		statuses = append(statuses, ref.toStatus())
+	}
+	myapp.Status.BrokenParentRefs = statuses
+
+	if len(broken) == 0 && len(objs.unreadable) > 0 {
+		unreadable := make([]string, 0, len(objs.unreadable))
+		for key := range objs.unreadable {
+			unreadable = append(unreadable, key.String())
+		}
+		slices.Sort(unreadable)
+		setCondition(myapp, webappv1.ConditionParentRefsResolved, metav1.ConditionUnknown,
+			webappv1.ReasonGatewayUnreadable, fmt.Sprintf(
+				"Gateways %s cannot be read, the parentRefs to them are not checked", strings.Join(unreadable, ", ")))
+		return
+	}
+	if len(broken) == 0 {
+		setCondition(myapp, webappv1.ConditionParentRefsResolved, metav1.ConditionTrue, webappv1.ReasonResolved,
+			"All parentRefs attach to a Gateway listener")
+		return
+	}
+	setCondition(myapp, webappv1.ConditionParentRefsResolved, metav1.ConditionFalse, webappv1.ReasonBrokenParentRefs,
+		fmt.Sprintf("%d parentRefs cannot be attached to a Gateway listener", len(broken)))
+
+	if r.Recorder == nil || equality.Semantic.DeepEqual(original.Status.BrokenParentRefs, myapp.Status.BrokenParentRefs) {
+		return
+	}
+	for i := range broken {
+		ref := broken[i]
+		r.Recorder.Eventf(myapp, corev1.EventTypeWarning, ref.reason, "%s %s: %s", ref.kind, ref.route, ref.message)
+		for _, route := range routes {
+			if routeKindOf(route) == ref.kind && client.ObjectKeyFromObject(route) == ref.route {
+				r.Recorder.Event(route, corev1.EventTypeWarning, ref.reason, ref.message)
+			}
+		}
+	}
+}
+
+// readGateways reads the Gateways referenced by the parentRefs of the routes,
+// their GatewayClasses and, when a listener selects route namespaces by label,
+// the namespaces of the routes.
+func (r *MyappReconciler) readGateways(ctx context.Context, routes []client.Object) gatewayObjects {
+	logger := logf.FromContext(ctx)
+	objs := gatewayObjects{
+		gateways:   make(map[types.NamespacedName]*gatewayv1.Gateway),
+		unreadable: make(map[types.NamespacedName]bool),
+		classes:    make(map[string]bool),
+		namespaces: make(map[string]*corev1.Namespace),
+	}
+
+	read := make(map[types.NamespacedName]bool)
+	for _, route := range routes {
+		for _, parentRef := range routeParentRefs(route) {
+			key, ok := gatewayKey(route.GetNamespace(), parentRef)
+			if !ok || read[key] {
+				continue
+			}
+			read[key] = true
+			var gateway gatewayv1.Gateway
+			if err := r.Get(ctx, key, &gateway); err != nil {
+				if !apierrors.IsNotFound(err) {
+					logger.Info("Failed to get Gateway, not checking the parentRefs to it", "gateway", key, "error", err)
+					objs.unreadable[key] = true
+				}
+				continue
+			}
+			objs.gateways[key] = &gateway
+		}
+	}
 
-	// Check if this is an HTTPRoute event
-	var httpRoute gatewayv1.HTTPRoute
-	if err := r.Get(ctx, req.NamespacedName, &httpRoute); err == nil {
-		logger.Info("HTTPRoute event detected\n",
-			"name", httpRoute.Name,
-			"namespace", httpRoute.Namespace,
-			"generation", httpRoute.Generation,
-			"resourceVersion", httpRoute.ResourceVersion)
+	selectsNamespaces := false
+	for _, gateway := range objs.gateways {
+		for _, listener := range gateway.Spec.Listeners {
+			if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil &&
+				listener.AllowedRoutes.Namespaces.From != nil &&
+				*listener.AllowedRoutes.Namespaces.From == gatewayv1.NamespacesFromSelector {
+				selectsNamespaces = true
+			}
+		}
+		name := string(gateway.Spec.GatewayClassName)
+		if _, ok := objs.classes[name]; ok {
+			continue
+		}
+		var gatewayClass gatewayv1.GatewayClass
+		err := r.Get(ctx, types.NamespacedName{Name: name}, &gatewayClass)
+		if err != nil && !apierrors.IsNotFound(err) {
+			logger.Info("Failed to get GatewayClass", "gatewayClass", name, "error", err)
+		}
+		// Only report GatewayClasses known not to exist
+		objs.classes[name] = !apierrors.IsNotFound(err)
+	}
 
-		// List all HTTPRoutes in the same namespace
-		var httpRoutes gatewayv1.HTTPRouteList
-		if err := r.List(ctx, &httpRoutes, client.InNamespace(req.Namespace)); err != nil {
-			logger.Info("Failed to list HTTPRoutes (Gateway API may not be available)", "error", err)
-		} else if len(httpRoutes.Items) > 0 {
-			logger.Info("<======== All HTTPRoutes in namespace =======>\n", "count", len(httpRoutes.Items), "namespace", req.Namespace)
+	if !selectsNamespaces {
+		return objs
+	}
+	for _, route := range routes {
+		name := route.GetNamespace()
+		if _, ok := objs.namespaces[name]; ok {
+			continue
+		}
+		var namespace corev1.Namespace
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, &namespace); err != nil {
+			logger.Info("Failed to get namespace, not checking label selectors of Gateway listeners",
+				"namespace", name, "error", err)
+			continue
 		}
-		return ctrl.Result{}, nil
+		objs.namespaces[name] = &namespace
 	}
+	return objs
+}
+
+// listNamespace lists the routes and Services of a namespace. It reports false
+// when HTTPRoutes or Services are unavailable, e.g. when the Gateway API is not
+// installed. Routes of optional kinds are only listed when installed.
+func (r *MyappReconciler) listNamespace(
+	ctx context.Context, namespace string,
+) ([]client.Object, []corev1.Service, bool) {
+	logger := logf.FromContext(ctx)
 
 	// List all HTTPRoutes in the same namespace to detect changes
 	var httpRoutes gatewayv1.HTTPRouteList
-	if err := r.List(ctx, &httpRoutes, client.InNamespace(req.Namespace)); err != nil {
+	if err := r.List(ctx, &httpRoutes, client.InNamespace(namespace)); err != nil {
 		logger.Info("Failed to list HTTPRoutes (Gateway API may not be available)", "error", err)
-		// Continue with Myapp processing even if HTTPRoute listing fails
-	} else if len(httpRoutes.Items) > 0 {
-		logger.Info("Found HTTPRoutes in namespace\n", "count", len(httpRoutes.Items), "namespace", req.Namespace)
-		// Log each HTTPRoute for debugging
-		for _, route := range httpRoutes.Items {
-			logger.Info("HTTPRoute in namespace",
-				"namespace", route.Namespace,
-				"name", route.Name,
-				"generation", route.Generation,
-				"resourceVersion", route.ResourceVersion)
-		}
-	}
-
-	// Check if this is an Service event
-	var service corev1.Service
-	if err := r.Get(ctx, req.NamespacedName, &service); err == nil {
-		logger.Info("Service event detected\n",
-			"name", service.Name,
-			"namespace", service.Namespace,
-			"generation", service.Generation,
-			"resourceVersion", service.ResourceVersion)
-
-		// List all Services in the same namespace
-		var services corev1.ServiceList
-		if err := r.List(ctx, &services, client.InNamespace(req.Namespace)); err != nil {
-			logger.Info("Failed to list Services", "error", err)
-		} else if len(services.Items) > 0 {
-			logger.Info("<======== All Services in namespace =======>\n", "count", len(services.Items), "namespace", req.Namespace)
-		}
-		return ctrl.Result{}, nil
+		return nil, nil, false
+	}
+	routes := append(routeObjects(httpRoutes.Items), r.listOptionalRoutes(ctx, namespace)...)
+	if len(routes) > 0 {
+		logger.Info("Found routes in namespace", "count", len(routes), "namespace", namespace)
+		for _, route := range routes {
+			logger.V(1).Info("Route in namespace",
+				"kind", routeKindOf(route),
+				"namespace", route.GetNamespace(),
+				"name", route.GetName(),
+				"generation", route.GetGeneration(),
+				"resourceVersion", route.GetResourceVersion())
+		}
 	}
 
 	// List all Services in the same namespace to detect changes
 	var services corev1.ServiceList
-	if err := r.List(ctx, &services, client.InNamespace(req.Namespace)); err != nil {
+	if err := r.List(ctx, &services, client.InNamespace(namespace)); err != nil {
 		logger.Info("Failed to list Services", "error", err)
-		// Continue with Myapp processing even if Services listing fails
+		return nil, nil, false
 	} else if len(services.Items) > 0 {
-		logger.Info("Found Services in namespace\n", "count", len(services.Items), "namespace", req.Namespace)
-
-		// Log each Service for debugging
+		logger.Info("Found Services in namespace", "count", len(services.Items), "namespace", namespace)
 		for _, service := range services.Items {
-			logger.Info("Service in namespace",
+			logger.V(1).Info("Service in namespace",
 				"namespace", service.Namespace,
 				"name", service.Name,
 				"generation", service.Generation,
@@ -128,28 +627,139 @@ func (r *MyappReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
-	// Try to fetch as Myapp instance
-	var myapp webappv1.Myapp
-	if err := r.Get(ctx, req.NamespacedName, &myapp); err != nil {
-		if client.IgnoreNotFound(err) == nil {
-			logger.Info("Resource MyApp not found in RequestNamespace. Ignoring since object must be deleted", "namespacedName", req.NamespacedName)
-			return ctrl.Result{}, nil
+	return routes, services.Items, true
+}
+
+// reconcileRoute creates or updates the route owned by the Myapp, of the kind
+// selected by its routing protocol, and deletes the routes of other kinds it
+// owns, for instance after the protocol changed or the routing section was
+// removed. It returns the route, or nil when the Myapp has no routing section.
+func (r *MyappReconciler) reconcileRoute(ctx context.Context, myapp *webappv1.Myapp) (client.Object, error) {
+	logger := logf.FromContext(ctx)
+
+	var route client.Object
+	if myapp.Spec.Routing != nil {
+		route = newRoute(protocolFor(myapp.Spec.Routing))
+	}
+	for _, protocol := range routeProtocols {
+		stale := newRoute(protocol)
+		if route != nil && routeKindOf(stale) == routeKindOf(route) {
+			continue
+		}
+		stale.SetName(myapp.Name)
+		if err := r.deleteRoute(ctx, myapp, stale); err != nil {
+			return nil, err
 		}
-		logger.Error(err, "Failed to get resource")
-		return ctrl.Result{}, err
+	}
+	if route == nil {
+		return nil, nil
 	}
 
-	logger.Info("Myapp event detected", "name", myapp.Name, "namespace", myapp.Namespace)
+	kind := routeKindOf(route)
+	route.SetName(myapp.Name)
+	route.SetNamespace(myapp.Namespace)
+	var drifted []string
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, route, func() error {
+		if err := r.checkControlled(myapp, route); err != nil {
+			return err
+		}
+		live := route.DeepCopyObject().(client.Object)
+		mutateRoute(myapp, route)
+		drifted = r.revertDrift(live, route, routeFields(route))
+		return controllerutil.SetControllerReference(myapp, route, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile %s %s/%s: %w", kind, route.GetNamespace(), route.GetName(), err)
+	}
+	logger.Info("Reconciled "+kind, "name", route.GetName(), "operation", op)
+	r.recordWrite(myapp, route, op)
+	r.recordDrift(myapp, route, drifted)
 
-	return ctrl.Result{}, nil
+	return route, nil
+}
+
+// deleteRoute deletes the route of the given kind and name in the Myapp
+// namespace, if it was generated for the Myapp.
+func (r *MyappReconciler) deleteRoute(ctx context.Context, myapp *webappv1.Myapp, route client.Object) error {
+	kind := routeKindOf(route)
+	key := client.ObjectKey{Namespace: myapp.Namespace, Name: route.GetName()}
+	if err := r.Get(ctx, key, route); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get %s %s: %w", kind, key, err)
+	}
+	// Never delete a route that was not generated for this Myapp
+	if !metav1.IsControlledBy(route, myapp) {
+		return nil
+	}
+	if err := r.Delete(ctx, route); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, key, err)
+	}
+	logf.FromContext(ctx).Info("Deleted "+kind, "name", route.GetName())
+	r.recordDelete(myapp, route)
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MyappReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	ctrl.Log.Info("Setting up controller with the manager")
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&webappv1.Myapp{}).
-		Watches(&gatewayv1.HTTPRoute{}, &handler.EnqueueRequestForObject{}).
-		Watches(&corev1.Service{}, &handler.EnqueueRequestForObject{}).
-		Complete(r)
+	if err := r.ServiceFilter.validate("Service"); err != nil {
+		return err
+	}
+	if err := r.HTTPRouteFilter.validate("HTTPRoute"); err != nil {
+		return err
+	}
+	for _, kind := range r.optionalRouteKinds() {
+		if err := kind.filter.validate(routeKindOf(kind.object)); err != nil {
+			return err
+		}
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&webappv1.Myapp{}, builder.WithPredicates(r.triggers.predicate("Myapp", objectKey))).
+		Owns(&appsv1.Deployment{}, builder.WithPredicates(r.triggers.predicate("Deployment", owningMyapp))).
+		Watches(&gatewayv1.HTTPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc("HTTPRoute", r.myappsForRoute)),
+			builder.WithPredicates(r.HTTPRouteFilter.predicate())).
+		Watches(&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc("Service", r.myappsForService)),
+			builder.WithPredicates(r.ServiceFilter.predicate())).
+		// Gateway controllers update the status of Gateways often, only spec
+		// changes affect which routes attach to them
+		Watches(&gatewayv1.Gateway{},
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc("Gateway", r.myappsForGateway)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&gatewayv1.GatewayClass{},
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc("GatewayClass", r.myappsForGatewayClass)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&gatewayv1beta1.ReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc("ReferenceGrant", r.myappsForReferenceGrant)))
+
+	// Watching a kind whose CRD is missing would keep the controller from starting
+	for _, kind := range r.optionalRouteKinds() {
+		name := routeKindOf(kind.object)
+		installed, err := kindInstalled(mgr, kind.object)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			ctrl.Log.Info("CRD not installed, not watching routes of this kind", "kind", name)
+			continue
+		}
+		b = b.Watches(kind.object,
+			handler.EnqueueRequestsFromMapFunc(r.triggers.mapFunc(name, r.myappsForRoute)),
+			builder.WithPredicates(kind.filter.predicate()))
+	}
+	return b.Complete(r)
+}
+
+// recordMyapps refreshes the Myapps by Ready metric of a namespace, and deletes
+// the series of the namespace once its last Myapp is gone.
+func (r *MyappReconciler) recordMyapps(ctx context.Context, namespace string) {
+	var myapps webappv1.MyappList
+	if err := r.List(ctx, &myapps, client.InNamespace(namespace)); err != nil {
+		logf.FromContext(ctx).Info("Failed to list Myapps for metrics", "error", err)
+		return
+	}
+	recordMyapps(namespace, myapps.Items)
 }
@@ -18,16 +18,32 @@ package controller
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 	gatewayapischeme "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/scheme"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -98,137 +114,2318 @@ var _ = Describe("Myapp Controller", func() {
 						Name:      resourceName,
 						Namespace: "default",
 					},
+					Spec: webappv1.MyappSpec{
+						Image: "tusova194/my_test_app:1.0.5",
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
 		})
 
-		AfterEach(func() {
-			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &webappv1.Myapp{}
-			err := k8sClient.Get(ctx, typeNamespacedName, resource)
-			Expect(err).NotTo(HaveOccurred())
+		AfterEach(func() {
+			// TODO(user): Cleanup logic after each test, like removing the resource instance.
+			resource := &webappv1.Myapp{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance Myapp")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Reconciling the deletion to release the finalizer")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should successfully reconcile the Myapp resource", func() {
+			By("Reconciling the created Myapp resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should handle non-existent Myapp resource gracefully", func() {
+			By("Reconciling a non-existent Myapp resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			nonExistentName := types.NamespacedName{
+				Name:      "non-existent-resource",
+				Namespace: "default",
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: nonExistentName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When reconciling an HTTPRoute resource", func() {
+		const httpRouteName = "test-httproute"
+
+		ctx := context.Background()
+
+		httpRouteNamespacedName := types.NamespacedName{
+			Name:      httpRouteName,
+			Namespace: "default",
+		}
+
+		It("should handle HTTPRoute reconciliation (if Gateway API is available)", func() {
+			By("Attempting to reconcile an HTTPRoute resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			// Try to reconcile an HTTPRoute - this should handle the case gracefully
+			// whether Gateway API is installed or not
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: httpRouteNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should handle non-existent HTTPRoute resource gracefully", func() {
+			By("Reconciling a non-existent HTTPRoute resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			nonExistentName := types.NamespacedName{
+				Name:      "non-existent-httproute",
+				Namespace: "default",
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: nonExistentName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When reconciling a Service resource", func() {
+		const serviceName = "test-service"
+
+		ctx := context.Background()
+
+		serviceNamespacedName := types.NamespacedName{
+			Name:      serviceName,
+			Namespace: "default",
+		}
+
+		It("should handle Service reconciliation", func() {
+			By("Attempting to reconcile a Service resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			// Try to reconcile a Service - this should handle the case gracefully
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: serviceNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should handle non-existent Service resource gracefully", func() {
+			By("Reconciling a non-existent Service resource")
+			controllerReconciler := &MyappReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			nonExistentName := types.NamespacedName{
+				Name:      "non-existent-service",
+				Namespace: "default",
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: nonExistentName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When reconciling a Myapp workload", func() {
+		ctx := context.Background()
+
+		var (
+			scheme     *runtime.Scheme
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "workload", Namespace: "default"}
+
+		BeforeEach(func() {
+			scheme = runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "workload-uid",
+				},
+				Spec: webappv1.MyappSpec{
+					Image:    "tusova194/my_test_app:1.0.5",
+					Replicas: ptr.To[int32](2),
+					Port:     3002,
+					Env:      []corev1.EnvVar{{Name: "MODE", Value: "test"}},
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme: scheme,
+			}
+		})
+
+		It("should create an owned Deployment and Service", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("checking the generated Deployment")
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(HaveValue(Equal(int32(2))))
+			Expect(deployment.Spec.Selector.MatchLabels).To(HaveKeyWithValue(myappLabel, key.Name))
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue(myappLabel, key.Name))
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal("tusova194/my_test_app:1.0.5"))
+			Expect(container.Ports).To(ConsistOf(HaveField("ContainerPort", int32(3002))))
+			Expect(container.Env).To(ConsistOf(corev1.EnvVar{Name: "MODE", Value: "test"}))
+			Expect(metav1.IsControlledBy(&deployment, myapp)).To(BeTrue())
+
+			By("checking the generated Service")
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			Expect(service.Spec.Selector).To(Equal(deployment.Spec.Selector.MatchLabels))
+			Expect(service.Spec.Ports).To(HaveLen(1))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(3002)))
+			Expect(service.Spec.Ports[0].TargetPort.StrVal).To(Equal(portName))
+			Expect(metav1.IsControlledBy(&service, myapp)).To(BeTrue())
+		})
+
+		It("should apply defaults for replicas and port", func() {
+			myapp.Spec.Replicas = nil
+			myapp.Spec.Port = 0
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(HaveValue(Equal(webappv1.DefaultReplicas)))
+
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			Expect(service.Spec.Ports[0].Port).To(Equal(webappv1.DefaultPort))
+		})
+
+		It("should keep the Deployment in sync with the Myapp spec", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("changing the image and replicas of the Myapp")
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			myapp.Spec.Replicas = ptr.To[int32](3)
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(HaveValue(Equal(int32(3))))
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.6"))
+		})
+
+		It("should leave a Deployment it does not control untouched", func() {
+			recorder := record.NewFakeRecorder(16)
+			reconciler.Recorder = recorder
+
+			manual := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "manual"}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "manual"}},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manual", Image: "nginx"}}},
+					},
+				},
+			}
+			Expect(reconciler.Create(ctx, manual)).To(Succeed())
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(conflictRequeueInterval))
+
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.OwnerReferences).To(BeEmpty())
+			Expect(deployment.Spec.Template.Spec.Containers).To(ConsistOf(HaveField("Name", "manual")))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			degraded := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(webappv1.ReasonObjectConflict))
+			Expect(drainEvents(recorder)).To(ConsistOf(
+				"Warning ObjectConflict Deployment default/workload already exists and was not generated for the Myapp, " +
+					"it is left untouched"))
+
+			By("reporting the conflict only once")
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).To(BeEmpty())
+
+			By("taking over once the Deployment is deleted")
+			Expect(reconciler.Delete(ctx, manual)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(metav1.IsControlledBy(&deployment, myapp)).To(BeTrue())
+		})
+
+		It("should set the Myapp probes with the API server defaults filled in", func() {
+			myapp.Spec.ReadinessProbe = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromString(portName)},
+			}}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.LivenessProbe).To(BeNil())
+			Expect(container.ReadinessProbe).NotTo(BeNil())
+			Expect(container.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+			Expect(container.ReadinessProbe.HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTP))
+			Expect(container.ReadinessProbe.PeriodSeconds).To(Equal(int32(10)))
+			Expect(container.ReadinessProbe.FailureThreshold).To(Equal(int32(3)))
+		})
+	})
+
+	Context("When reconciling a Myapp with routing", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "routed", Namespace: "default"}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "routed-uid",
+				},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Port:  3002,
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs: []webappv1.ParentRef{{Name: "gateway", SectionName: "http"}},
+						Hostnames:  []string{"test.my-apps.com"},
+						Paths:      []webappv1.PathMatch{{Type: webappv1.PathMatchPathPrefix, Value: "/test"}},
+					},
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme: scheme,
+			}
+		})
+
+		It("should create an owned HTTPRoute pointing at the Myapp Service", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(metav1.IsControlledBy(&route, myapp)).To(BeTrue())
+			Expect(route.Spec.ParentRefs).To(HaveLen(1))
+			Expect(route.Spec.ParentRefs[0].Name).To(Equal(gatewayv1.ObjectName("gateway")))
+			Expect(route.Spec.ParentRefs[0].SectionName).To(HaveValue(Equal(gatewayv1.SectionName("http"))))
+			Expect(route.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("test.my-apps.com")))
+			Expect(route.Spec.Rules).To(HaveLen(1))
+
+			rule := route.Spec.Rules[0]
+			Expect(rule.Matches).To(HaveLen(1))
+			Expect(rule.Matches[0].Path.Value).To(HaveValue(Equal("/test")))
+			Expect(rule.BackendRefs).To(HaveLen(1))
+			Expect(rule.BackendRefs[0].Name).To(Equal(gatewayv1.ObjectName(key.Name)))
+			Expect(rule.BackendRefs[0].Port).To(HaveValue(Equal(gatewayv1.PortNumber(3002))))
+		})
+
+		It("should default the path match to the root prefix", func() {
+			myapp.Spec.Routing.Paths = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(route.Spec.Rules[0].Matches).To(HaveLen(1))
+			Expect(route.Spec.Rules[0].Matches[0].Path.Type).To(HaveValue(Equal(gatewayv1.PathMatchPathPrefix)))
+			Expect(route.Spec.Rules[0].Matches[0].Path.Value).To(HaveValue(Equal("/")))
+		})
+
+		It("should delete the HTTPRoute when routing is removed", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Routing = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var route gatewayv1.HTTPRoute
+			err = reconciler.Get(ctx, key, &route)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should leave an HTTPRoute it does not own untouched", func() {
+			myapp.Spec.Routing = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			manual := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			}
+			Expect(reconciler.Create(ctx, manual)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+		})
+
+		It("should not take over an HTTPRoute it does not own", func() {
+			manual := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec:       gatewayv1.HTTPRouteSpec{Hostnames: []gatewayv1.Hostname{"manual.my-apps.com"}},
+			}
+			Expect(reconciler.Create(ctx, manual)).To(Succeed())
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(conflictRequeueInterval))
+
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(route.OwnerReferences).To(BeEmpty())
+			Expect(route.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("manual.my-apps.com")))
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			degraded := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Reason).To(Equal(webappv1.ReasonObjectConflict))
+			Expect(degraded.Message).To(HavePrefix("HTTPRoute default/" + key.Name + " already exists"))
+		})
+
+		It("should create a GRPCRoute with method matches for the GRPC protocol", func() {
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolGRPC
+			myapp.Spec.Routing.Paths = nil
+			myapp.Spec.Routing.Methods = []webappv1.MethodMatch{{Service: "helloworld.Greeter", Method: "SayHello"}}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var route gatewayv1.GRPCRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(metav1.IsControlledBy(&route, myapp)).To(BeTrue())
+			Expect(route.Labels).To(HaveKeyWithValue(managedLabel, "true"))
+			Expect(route.Spec.ParentRefs).To(HaveLen(1))
+			Expect(route.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("test.my-apps.com")))
+			Expect(route.Spec.Rules).To(HaveLen(1))
+
+			rule := route.Spec.Rules[0]
+			Expect(rule.Matches).To(HaveLen(1))
+			Expect(rule.Matches[0].Method.Type).To(HaveValue(Equal(gatewayv1.GRPCMethodMatchExact)))
+			Expect(rule.Matches[0].Method.Service).To(HaveValue(Equal("helloworld.Greeter")))
+			Expect(rule.Matches[0].Method.Method).To(HaveValue(Equal("SayHello")))
+			Expect(rule.BackendRefs).To(HaveLen(1))
+			Expect(rule.BackendRefs[0].Port).To(HaveValue(Equal(gatewayv1.PortNumber(3002))))
+
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			Expect(service.Spec.Ports[0].AppProtocol).To(HaveValue(Equal(h2cAppProtocol)))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.URL).To(Equal("grpc://test.my-apps.com"))
+		})
+
+		It("should replace the HTTPRoute with a GRPCRoute when the protocol changes", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolGRPC
+			myapp.Spec.Routing.Paths = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var httpRoute gatewayv1.HTTPRoute
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &httpRoute))).To(BeTrue())
+			var grpcRoute gatewayv1.GRPCRoute
+			Expect(reconciler.Get(ctx, key, &grpcRoute)).To(Succeed())
+			By("routing every service and method without method matches")
+			Expect(grpcRoute.Spec.Rules[0].Matches).To(BeEmpty())
+		})
+
+		It("should create TCPRoutes and TLSRoutes for layer 4 protocols", func() {
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolTLS
+			myapp.Spec.Routing.Paths = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var tlsRoute gatewayv1alpha2.TLSRoute
+			Expect(reconciler.Get(ctx, key, &tlsRoute)).To(Succeed())
+			Expect(metav1.IsControlledBy(&tlsRoute, myapp)).To(BeTrue())
+			Expect(tlsRoute.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("test.my-apps.com")))
+			Expect(tlsRoute.Spec.Rules).To(HaveLen(1))
+			Expect(tlsRoute.Spec.Rules[0].BackendRefs).To(HaveLen(1))
+			Expect(tlsRoute.Spec.Rules[0].BackendRefs[0].Port).To(HaveValue(Equal(gatewayv1.PortNumber(3002))))
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.URL).To(Equal("tls://test.my-apps.com"))
+
+			By("switching to TCP")
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolTCP
+			myapp.Spec.Routing.Hostnames = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &tlsRoute))).To(BeTrue())
+			var tcpRoute gatewayv1alpha2.TCPRoute
+			Expect(reconciler.Get(ctx, key, &tcpRoute)).To(Succeed())
+			Expect(tcpRoute.Spec.Rules[0].BackendRefs[0].Name).To(Equal(gatewayv1.ObjectName(key.Name)))
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.URL).To(BeEmpty())
+		})
+
+		It("should record Events for written objects and failed reconciles", func() {
+			recorder := record.NewFakeRecorder(32)
+			reconciler.Recorder = recorder
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			// Along with the broken parentRef, as the Gateway does not exist
+			Expect(drainEvents(recorder)).To(ContainElements(
+				"Normal Created Created Deployment routed",
+				"Normal Created Created Service routed",
+				"Normal Created Created HTTPRoute routed",
+				"Normal Created Created for Myapp routed",
+				"Normal Created Created for Myapp routed",
+				"Normal Created Created for Myapp routed",
+			))
+
+			By("reconciling again without changes")
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).To(BeEmpty())
+
+			By("replacing the HTTPRoute with a GRPCRoute")
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolGRPC
+			myapp.Spec.Routing.Paths = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).To(ContainElements(
+				"Normal Deleted Deleted HTTPRoute routed",
+				"Normal Created Created GRPCRoute routed",
+				"Normal Updated Updated Service routed",
+			))
+
+			By("failing to update the Deployment")
+			reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*appsv1.Deployment); ok {
+						return errors.NewServiceUnavailable("etcd is down")
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			})
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).To(HaveOccurred())
+			Expect(drainEvents(recorder)).To(ConsistOf(
+				"Warning ReconcileFailed failed to reconcile Deployment default/routed: etcd is down",
+			))
+		})
+
+		It("should revert drift of generated objects, except hand-managed fields", func() {
+			recorder := record.NewFakeRecorder(32)
+			reconciler.Recorder = recorder
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			drainEvents(recorder)
+
+			By("editing the generated objects by hand")
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			deployment.Spec.Replicas = ptr.To[int32](5)
+			deployment.Spec.Template.Spec.Containers[0].Image = "tusova194/my_test_app:hotfix"
+			deployment.Labels["team"] = "shop"
+			Expect(reconciler.Update(ctx, &deployment)).To(Succeed())
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			service.Spec.Selector = map[string]string{"app": "other"}
+			Expect(reconciler.Update(ctx, &service)).To(Succeed())
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			route.Spec.Hostnames = append(route.Spec.Hostnames, "hijacked.my-apps.com")
+			Expect(reconciler.Update(ctx, &route)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).To(ContainElements(
+				"Warning Drifted Reverted drift of Deployment routed: spec.replicas, spec.template.spec.containers[app].image",
+				"Warning Drifted Reverted drift of Service routed: spec.selector",
+				"Warning Drifted Reverted drift of HTTPRoute routed: spec.hostnames",
+				"Warning Drifted Reverted drift of spec.selector for Myapp routed",
+			))
+
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(HaveValue(BeEquivalentTo(webappv1.DefaultReplicas)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.5"))
+			// Labels the controller does not set are not managed
+			Expect(deployment.Labels).To(HaveKeyWithValue("team", "shop"))
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			Expect(service.Spec.Selector).To(Equal(selectorLabels(myapp)))
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(route.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("test.my-apps.com")))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.Drift).To(ConsistOf(
+				HaveField("Name", key.Name),
+				HaveField("Name", key.Name),
+				HaveField("Name", key.Name),
+			))
+			Expect(myapp.Status.Drift[0].Kind).To(Equal("Deployment"))
+			Expect(myapp.Status.Drift[0].Fields).To(Equal([]string{
+				"spec.replicas", "spec.template.spec.containers[app].image",
+			}))
+			Expect(myapp.Status.Drift[0].LastDetectedTime.IsZero()).To(BeFalse())
+
+			By("handing the replicas over, for instance to an autoscaler")
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			deployment.Annotations[handManagedAnnotation] = "spec.replicas, spec.paused"
+			deployment.Spec.Replicas = ptr.To[int32](7)
+			Expect(reconciler.Update(ctx, &deployment)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			events := drainEvents(recorder)
+			Expect(events).To(ContainElement(
+				"Warning UnknownHandManagedField spec.paused is not a field managed for Myapp routed"))
+			Expect(events).NotTo(ContainElement(ContainSubstring("Drifted")))
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(HaveValue(BeEquivalentTo(7)))
+
+			By("reporting the unknown field once")
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).To(BeEmpty())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			resolved := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionHandManagedFieldsResolved)
+			Expect(resolved).NotTo(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolved.Message).To(ContainSubstring("Deployment routed spec.paused"))
+
+			By("changing the spec, which is not drift")
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			myapp.Spec.Routing.Hostnames = []string{"next.my-apps.com"}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drainEvents(recorder)).NotTo(ContainElement(ContainSubstring("Drifted")))
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.6"))
+			Expect(deployment.Spec.Replicas).To(HaveValue(BeEquivalentTo(7)))
+		})
+
+		It("should not report values defaulted by the API server as drift", func() {
+			recorder := record.NewFakeRecorder(32)
+			reconciler.Recorder = recorder
+			// The applied fields are recorded in the same write as the object
+			patched := 0
+			// Default the apiVersion of fieldRefs like the API server
+			defaultEnv := func(obj client.Object) {
+				if deployment, ok := obj.(*appsv1.Deployment); ok {
+					for _, env := range appContainer(deployment).Env {
+						if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.APIVersion == "" {
+							env.ValueFrom.FieldRef.APIVersion = "v1"
+						}
+					}
+				}
+			}
+			reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					defaultEnv(obj)
+					return c.Create(ctx, obj, opts...)
+				},
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					defaultEnv(obj)
+					return c.Update(ctx, obj, opts...)
+				},
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+					patched++
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			})
+			myapp.Spec.Env = []corev1.EnvVar{{
+				Name:      "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+			}}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			for range 3 {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(drainEvents(recorder)).NotTo(ContainElement(ContainSubstring("Drifted")))
+			Expect(patched).To(BeZero())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.Drift).To(BeEmpty())
+
+			By("keeping the detection time while the same fields drift again")
+			editImage := func(image string) {
+				var deployment appsv1.Deployment
+				Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+				deployment.Spec.Template.Spec.Containers[0].Image = image
+				Expect(reconciler.Update(ctx, &deployment)).To(Succeed())
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			}
+			editImage("tusova194/my_test_app:hotfix")
+			Expect(myapp.Status.Drift).To(HaveLen(1))
+			detected := myapp.Status.Drift[0].LastDetectedTime
+			myapp.Status.Drift[0].LastDetectedTime = metav1.NewTime(detected.Add(-time.Hour))
+			Expect(reconciler.Status().Update(ctx, myapp)).To(Succeed())
+			editImage("tusova194/my_test_app:hotfix-2")
+			Expect(myapp.Status.Drift[0].LastDetectedTime.Time).To(BeTemporally("==", detected.Add(-time.Hour)))
+		})
+
+		It("should report a missing route CRD without failing the reconcile", func() {
+			// Pretend the experimental channel CRDs are not installed
+			notInstalled := func(obj runtime.Object) error {
+				switch obj.(type) {
+				case *gatewayv1alpha2.TCPRoute, *gatewayv1alpha2.TLSRoute:
+					return &meta.NoKindMatchError{GroupKind: gatewayv1alpha2.SchemeGroupVersion.WithKind("TCPRoute").GroupKind()}
+				}
+				return nil
+			}
+			reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if err := notInstalled(obj); err != nil {
+						return err
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if err := notInstalled(obj); err != nil {
+						return err
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			})
+
+			myapp.Spec.Routing.Protocol = webappv1.RouteProtocolTCP
+			myapp.Spec.Routing.Paths = nil
+			myapp.Spec.Routing.Hostnames = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(missingRouteKindRequeueInterval))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			ready := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(webappv1.ReasonRouteKindNotInstalled))
+			Expect(ready.Message).To(ContainSubstring("TCPRoute"))
+		})
+	})
+
+	Context("When deleting a Myapp", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "cleanup", Namespace: "default"}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "cleanup-uid",
+				},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs: []webappv1.ParentRef{{Name: "gateway"}},
+					},
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme: scheme,
+			}
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+		})
+
+		It("should add the cleanup finalizer", func() {
+			Expect(myapp.Finalizers).To(ContainElement(myappFinalizer))
+		})
+
+		It("should delete generated objects in every namespace before releasing the Myapp", func() {
+			By("creating a generated object in another namespace")
+			crossNamespace := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cleanup-mirror",
+					Namespace: "other",
+					Labels: map[string]string{
+						myappLabel:          key.Name,
+						myappNamespaceLabel: key.Namespace,
+					},
+				},
+			}
+			Expect(reconciler.Create(ctx, crossNamespace)).To(Succeed())
+
+			By("creating an object of another Myapp with the same name in another namespace")
+			unrelated := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: "elsewhere",
+					Labels: map[string]string{
+						myappLabel:          key.Name,
+						myappNamespaceLabel: "elsewhere",
+					},
+				},
+			}
+			Expect(reconciler.Create(ctx, unrelated)).To(Succeed())
+
+			Expect(reconciler.Delete(ctx, myapp)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &corev1.Service{}))).To(BeTrue())
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &gatewayv1.HTTPRoute{}))).To(BeTrue())
+			Expect(errors.IsNotFound(reconciler.Get(ctx,
+				types.NamespacedName{Namespace: "other", Name: "cleanup-mirror"}, &corev1.Service{}))).To(BeTrue())
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(unrelated), &corev1.Service{})).To(Succeed())
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &webappv1.Myapp{}))).To(BeTrue())
+		})
+
+		It("should keep the finalizer while generated objects are terminating", func() {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			route.Finalizers = []string{"example.com/hold"}
+			Expect(reconciler.Update(ctx, &route)).To(Succeed())
+
+			Expect(reconciler.Delete(ctx, myapp)).To(Succeed())
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(cleanupRequeueInterval))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Finalizers).To(ContainElement(myappFinalizer))
+			ready := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Reason).To(Equal(webappv1.ReasonDeleting))
+
+			By("releasing the generated object")
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			route.Finalizers = nil
+			Expect(reconciler.Update(ctx, &route)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &webappv1.Myapp{}))).To(BeTrue())
+		})
+	})
+
+	Context("When reporting the Myapp status", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "status", Namespace: "default"}
+
+		// markDeploymentAvailable reports all desired replicas of the generated Deployment as available.
+		markDeploymentAvailable := func() {
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			deployment.Status.ObservedGeneration = deployment.Generation
+			deployment.Status.Replicas = *deployment.Spec.Replicas
+			deployment.Status.UpdatedReplicas = *deployment.Spec.Replicas
+			deployment.Status.ReadyReplicas = *deployment.Spec.Replicas
+			deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+			Expect(reconciler.Status().Update(ctx, &deployment)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       key.Name,
+					Namespace:  key.Namespace,
+					UID:        "status-uid",
+					Generation: 1,
+				},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}, &appsv1.Deployment{}, &gatewayv1.HTTPRoute{}).
+					Build(),
+				Scheme: scheme,
+			}
+		})
+
+		It("should report Progressing until the Deployment is available", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.ObservedGeneration).To(Equal(myapp.Generation))
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionProgressing)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionReady)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionDegraded)).To(BeTrue())
+			Expect(meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionRouteAccepted)).To(BeNil())
+
+			By("marking the Deployment as available")
+			markDeploymentAvailable()
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionReady)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionProgressing)).To(BeTrue())
+		})
+
+		It("should report the image, replicas and URL shown by kubectl get", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			markDeploymentAvailable()
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.Image).To(Equal("tusova194/my_test_app:1.0.5"))
+			Expect(myapp.Status.Replicas).To(Equal(webappv1.DefaultReplicas))
+			Expect(myapp.Status.ReadyReplicas).To(Equal(webappv1.DefaultReplicas))
+			Expect(myapp.Status.URL).To(Equal("http://status.default.svc:8080"))
+
+			By("routing the Myapp through a hostname")
+			myapp.Spec.Routing = &webappv1.RoutingSpec{
+				ParentRefs: []webappv1.ParentRef{{Name: "gateway"}},
+				Hostnames:  []string{"*.my-apps.com", "status.my-apps.com"},
+				Paths:      []webappv1.PathMatch{{Value: "/status"}},
+			}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.URL).To(Equal("http://status.my-apps.com/status"))
+		})
+
+		It("should only be Ready once the HTTPRoute is accepted", func() {
+			myapp.Spec.Routing = &webappv1.RoutingSpec{
+				ParentRefs: []webappv1.ParentRef{{Name: "gateway"}},
+			}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			markDeploymentAvailable()
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			routeAccepted := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionRouteAccepted)
+			Expect(routeAccepted).NotTo(BeNil())
+			Expect(routeAccepted.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionReady)).To(BeTrue())
+
+			By("accepting the HTTPRoute on its Gateway")
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			route.Status.Parents = []gatewayv1.RouteParentStatus{{
+				ParentRef:      gatewayv1.ParentReference{Name: "gateway"},
+				ControllerName: "example.com/gateway-controller",
+				Conditions: []metav1.Condition{{
+					Type:               string(gatewayv1.RouteConditionAccepted),
+					Status:             metav1.ConditionTrue,
+					Reason:             string(gatewayv1.RouteReasonAccepted),
+					LastTransitionTime: metav1.Now(),
+				}},
+			}}
+			Expect(reconciler.Status().Update(ctx, &route)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionRouteAccepted)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionReady)).To(BeTrue())
+		})
+
+		It("should report Degraded when the Deployment exceeds its progress deadline", func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+				Type:   appsv1.DeploymentProgressing,
+				Status: corev1.ConditionFalse,
+				Reason: "ProgressDeadlineExceeded",
+			}}
+			Expect(reconciler.Status().Update(ctx, &deployment)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			degraded := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(webappv1.ReasonProgressDeadlineExceeded))
+		})
+	})
+
+	Context("When rolling out a Myapp with a canary", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "canary", Namespace: "default"}
+		canaryKey := types.NamespacedName{Name: "canary-canary", Namespace: "default"}
+
+		reconcileMyapp := func() reconcile.Result {
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			return result
+		}
+
+		markAvailable := func(name string) {
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: key.Namespace}, &deployment)).To(Succeed())
+			deployment.Status.ObservedGeneration = deployment.Generation
+			deployment.Status.Replicas = *deployment.Spec.Replicas
+			deployment.Status.UpdatedReplicas = *deployment.Spec.Replicas
+			deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+			Expect(reconciler.Status().Update(ctx, &deployment)).To(Succeed())
+		}
+
+		deploymentImage := func(name string) string {
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: key.Namespace}, &deployment)).To(Succeed())
+			return deployment.Spec.Template.Spec.Containers[0].Image
+		}
+
+		// defaultTemplate sets Pod template fields the API server defaults.
+		defaultTemplate := func(name string) {
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: key.Namespace}, &deployment)).To(Succeed())
+			container := &deployment.Spec.Template.Spec.Containers[0]
+			container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+			container.ImagePullPolicy = corev1.PullIfNotPresent
+			deployment.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+			Expect(reconciler.Update(ctx, &deployment)).To(Succeed())
+		}
+
+		// routeWeights returns the weight of each backend Service of the generated HTTPRoute.
+		routeWeights := func() map[string]int32 {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			weights := make(map[string]int32)
+			for _, ref := range route.Spec.Rules[0].BackendRefs {
+				weights[string(ref.Name)] = *ref.Weight
+			}
+			return weights
+		}
+
+		setAnnotation := func(name, value string) {
+			myapp.Annotations = map[string]string{name: value}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "canary-uid",
+				},
+				Spec: webappv1.MyappSpec{
+					Image:    "tusova194/my_test_app:1.0.5",
+					Replicas: ptr.To[int32](4),
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs: []webappv1.ParentRef{{Name: "gateway"}},
+						BackendRefs: []webappv1.BackendRef{
+							{Name: "legacy", Port: 80, Weight: ptr.To[int32](2)},
+						},
+					},
+					Rollout: &webappv1.RolloutSpec{Canary: &webappv1.CanaryStrategy{
+						Steps: []webappv1.CanaryStep{
+							{Weight: 10, Pause: &metav1.Duration{Duration: time.Hour}},
+							{Weight: 50},
+						},
+					}},
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}, &appsv1.Deployment{}).
+					Build(),
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(256),
+			}
+
+			By("creating the Deployment with the first image directly")
+			reconcileMyapp()
+			Expect(myapp.Status.Canary).To(BeNil())
+			markAvailable(key.Name)
+			Expect(reconciler.Get(ctx, canaryKey, &appsv1.Deployment{})).NotTo(Succeed())
+
+			By("changing the image")
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+		})
+
+		It("should shift traffic through the steps and promote the new version", func() {
+			result := reconcileMyapp()
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			Expect(myapp.Status.Canary).NotTo(BeNil())
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhaseProgressing))
+			Expect(myapp.Status.Canary.Step).To(BeZero())
+			Expect(myapp.Status.Canary.Weight).To(Equal(int32(10)))
+			Expect(deploymentImage(key.Name)).To(Equal("tusova194/my_test_app:1.0.5"))
+			Expect(deploymentImage(canaryKey.Name)).To(Equal("tusova194/my_test_app:1.0.6"))
+			Expect(routeWeights()).To(Equal(map[string]int32{"canary": 90, "canary-canary": 10, "legacy": 200}))
+
+			var canary appsv1.Deployment
+			Expect(reconciler.Get(ctx, canaryKey, &canary)).To(Succeed())
+			Expect(canary.Spec.Replicas).To(HaveValue(Equal(int32(1))))
+			Expect(canary.Spec.Selector.MatchLabels).To(HaveKeyWithValue(trackLabel, canaryTrack))
+			Expect(canary.Spec.Template.Labels).NotTo(HaveKey(myappLabel))
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, canaryKey, &service)).To(Succeed())
+			Expect(service.Spec.Selector).To(Equal(canarySelectorLabels(myapp)))
+
+			By("letting the pause of the first step elapse")
+			myapp.Status.Canary.StepStartTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+			Expect(reconciler.Status().Update(ctx, myapp)).To(Succeed())
+			result = reconcileMyapp()
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhasePaused))
+			Expect(myapp.Status.Canary.Step).To(Equal(int32(1)))
+			Expect(routeWeights()).To(HaveKeyWithValue("canary-canary", int32(50)))
+			progressing := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionProgressing)
+			Expect(progressing).NotTo(BeNil())
+			Expect(progressing.Reason).To(Equal(webappv1.ReasonCanaryPaused))
+
+			By("staying at the step without a pause")
+			reconcileMyapp()
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhasePaused))
+
+			By("promoting the canary")
+			setAnnotation(webappv1.PromoteAnnotation, webappv1.PromoteFull)
+			reconcileMyapp()
+			Expect(myapp.Annotations).NotTo(HaveKey(webappv1.PromoteAnnotation))
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhasePromoting))
+			Expect(deploymentImage(key.Name)).To(Equal("tusova194/my_test_app:1.0.6"))
+			Expect(routeWeights()).To(HaveKeyWithValue("canary", int32(0)))
+			Expect(routeWeights()).To(HaveKeyWithValue("canary-canary", int32(100)))
+			Expect(reconciler.Get(ctx, canaryKey, &canary)).To(Succeed())
+			Expect(canary.Spec.Replicas).To(HaveValue(Equal(int32(4))))
+
+			By("completing the rollout once the defaulted Deployment is available")
+			defaultTemplate(key.Name)
+			markAvailable(key.Name)
+			reconcileMyapp()
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhaseCompleted))
+			Expect(routeWeights()).To(Equal(map[string]int32{"canary": 1, "legacy": 2}))
+			Expect(errors.IsNotFound(reconciler.Get(ctx, canaryKey, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(errors.IsNotFound(reconciler.Get(ctx, canaryKey, &corev1.Service{}))).To(BeTrue())
+			var reasons []string
+			for _, event := range drainEvents(reconciler.Recorder.(*record.FakeRecorder)) {
+				reasons = append(reasons, strings.Fields(event)[1])
+			}
+			Expect(reasons).To(ContainElements("CanaryStarted", "CanaryCompleted"))
+			Expect(reasons).To(ContainElement(reasonDeleted), "the canary objects are deleted")
+		})
+
+		It("should not take over canary objects or run next to a Deployment it does not control", func() {
+			manual := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: canaryKey.Name, Namespace: canaryKey.Namespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "manual"}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "manual"}},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manual", Image: "nginx"}}},
+					},
+				},
+			}
+			Expect(reconciler.Create(ctx, manual)).To(Succeed())
+
+			result := reconcileMyapp()
+			Expect(result.RequeueAfter).To(Equal(conflictRequeueInterval))
+			Expect(deploymentImage(canaryKey.Name)).To(Equal("nginx"))
+			degraded := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Reason).To(Equal(webappv1.ReasonObjectConflict))
+
+			By("handing the stable Deployment over to someone else")
+			Expect(reconciler.Delete(ctx, manual)).To(Succeed())
+			var stable appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &stable)).To(Succeed())
+			stable.OwnerReferences = nil
+			Expect(reconciler.Update(ctx, &stable)).To(Succeed())
+
+			result = reconcileMyapp()
+			Expect(result.RequeueAfter).To(Equal(conflictRequeueInterval))
+			Expect(errors.IsNotFound(reconciler.Get(ctx, canaryKey, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(deploymentImage(key.Name)).To(Equal("tusova194/my_test_app:1.0.5"))
+		})
+
+		It("should keep the previous version when the rollout is aborted", func() {
+			reconcileMyapp()
+
+			By("promoting the canary by a single step")
+			setAnnotation(webappv1.PromoteAnnotation, "")
+			reconcileMyapp()
+			Expect(myapp.Status.Canary.Step).To(Equal(int32(1)))
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhasePaused))
+
+			By("aborting the rollout")
+			setAnnotation(webappv1.AbortAnnotation, "true")
+			reconcileMyapp()
+			Expect(myapp.Annotations).NotTo(HaveKey(webappv1.AbortAnnotation))
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhaseAborted))
+			Expect(routeWeights()).To(Equal(map[string]int32{"canary": 1, "legacy": 2}))
+			Expect(errors.IsNotFound(reconciler.Get(ctx, canaryKey, &appsv1.Deployment{}))).To(BeTrue())
+
+			reconcileMyapp()
+			Expect(deploymentImage(key.Name)).To(Equal("tusova194/my_test_app:1.0.5"))
+
+			By("starting a new rollout when the image changes again")
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.7"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			reconcileMyapp()
+			Expect(myapp.Status.Canary.Phase).To(Equal(webappv1.CanaryPhaseProgressing))
+			Expect(deploymentImage(canaryKey.Name)).To(Equal("tusova194/my_test_app:1.0.7"))
+		})
+	})
+
+	Context("When rolling out a Myapp with blue/green", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler *MyappReconciler
+			myapp      *webappv1.Myapp
+		)
+
+		key := types.NamespacedName{Name: "bluegreen", Namespace: "default"}
+		blueKey := types.NamespacedName{Name: "bluegreen-blue", Namespace: "default"}
+		greenKey := types.NamespacedName{Name: "bluegreen-green", Namespace: "default"}
+		previewKey := types.NamespacedName{Name: "bluegreen-preview", Namespace: "default"}
+
+		reconcileMyapp := func() reconcile.Result {
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			return result
+		}
+
+		getDeployment := func(key types.NamespacedName) *appsv1.Deployment {
+			var deployment appsv1.Deployment
+			Expect(reconciler.Get(ctx, key, &deployment)).To(Succeed())
+			return &deployment
+		}
+
+		markAvailable := func(key types.NamespacedName) {
+			deployment := getDeployment(key)
+			deployment.Status.ObservedGeneration = deployment.Generation
+			deployment.Status.Replicas = *deployment.Spec.Replicas
+			deployment.Status.UpdatedReplicas = *deployment.Spec.Replicas
+			deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+			Expect(reconciler.Status().Update(ctx, deployment)).To(Succeed())
+		}
+
+		// routeBackend returns the name of the only backend Service of an HTTPRoute.
+		routeBackend := func(key types.NamespacedName) string {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &route)).To(Succeed())
+			Expect(route.Spec.Rules[0].BackendRefs).To(HaveLen(1))
+			return string(route.Spec.Rules[0].BackendRefs[0].Name)
+		}
+
+		serviceSelector := func() map[string]string {
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, key, &service)).To(Succeed())
+			return service.Spec.Selector
+		}
+
+		// elapseScaleDownDelay moves the scale-down time of the previous color to the past.
+		elapseScaleDownDelay := func() {
+			Expect(myapp.Status.BlueGreen.ScaleDownTime).NotTo(BeNil())
+			myapp.Status.BlueGreen.ScaleDownTime = &metav1.Time{Time: time.Now().Add(-time.Second)}
+			Expect(reconciler.Status().Update(ctx, myapp)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp = &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					UID:       "bluegreen-uid",
+				},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs: []webappv1.ParentRef{{Name: "gateway"}},
+						Hostnames:  []string{"test.my-apps.com"},
+					},
+					Rollout: &webappv1.RolloutSpec{BlueGreen: &webappv1.BlueGreenStrategy{
+						ScaleDownDelay:   &metav1.Duration{Duration: time.Minute},
+						PreviewHostnames: []string{"preview.my-apps.com"},
+					}},
+				},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp).
+					WithStatusSubresource(&webappv1.Myapp{}, &appsv1.Deployment{}).
+					Build(),
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(256),
+			}
+		})
+
+		It("should switch the route to the new color once it is available", func() {
+			By("starting the first color as the active one")
+			reconcileMyapp()
+			Expect(myapp.Status.BlueGreen).To(Equal(&webappv1.BlueGreenStatus{ActiveColor: webappv1.ColorBlue}))
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(getDeployment(blueKey).Spec.Template.Labels).To(HaveKeyWithValue(colorLabel, "blue"))
+			Expect(routeBackend(key)).To(Equal(blueKey.Name))
+			Expect(serviceSelector()).To(Equal(colorSelectorLabels(myapp, webappv1.ColorBlue)))
+			markAvailable(blueKey)
+
+			By("previewing a new image on the other color")
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			reconcileMyapp()
+			Expect(myapp.Status.BlueGreen.ActiveColor).To(Equal(webappv1.ColorBlue))
+			Expect(myapp.Status.BlueGreen.PreviewColor).To(Equal(webappv1.ColorGreen))
+			Expect(getDeployment(blueKey).Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.5"))
+			Expect(getDeployment(greenKey).Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.6"))
+			Expect(routeBackend(key)).To(Equal(blueKey.Name))
+			Expect(routeBackend(previewKey)).To(Equal(greenKey.Name))
+			var preview gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, previewKey, &preview)).To(Succeed())
+			Expect(preview.Spec.Hostnames).To(ConsistOf(gatewayv1.Hostname("preview.my-apps.com")))
+			progressing := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionProgressing)
+			Expect(progressing).NotTo(BeNil())
+			Expect(progressing.Reason).To(Equal(webappv1.ReasonBlueGreenPreviewing))
+
+			By("switching once the new color is available")
+			markAvailable(greenKey)
+			result := reconcileMyapp()
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(myapp.Status.BlueGreen.ActiveColor).To(Equal(webappv1.ColorGreen))
+			Expect(myapp.Status.BlueGreen.PreviewColor).To(BeEmpty())
+			Expect(routeBackend(key)).To(Equal(greenKey.Name))
+			Expect(serviceSelector()).To(Equal(colorSelectorLabels(myapp, webappv1.ColorGreen)))
+			Expect(errors.IsNotFound(reconciler.Get(ctx, previewKey, &gatewayv1.HTTPRoute{}))).To(BeTrue())
+			Expect(myapp.Status.Image).To(Equal("tusova194/my_test_app:1.0.6"))
+
+			By("keeping the previous color for the scale-down delay")
+			reconcileMyapp()
+			Expect(getDeployment(blueKey).Spec.Replicas).To(HaveValue(Equal(int32(1))))
+			elapseScaleDownDelay()
+			result = reconcileMyapp()
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(getDeployment(blueKey).Spec.Replicas).To(HaveValue(BeZero()))
+			Expect(myapp.Status.BlueGreen.ScaleDownTime).To(BeNil())
+		})
+
+		It("should not take over color objects or a preview route it does not control", func() {
+			expectConflict := func(result reconcile.Result, kind string, name types.NamespacedName) {
+				Expect(result.RequeueAfter).To(Equal(conflictRequeueInterval))
+				degraded := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionDegraded)
+				Expect(degraded).NotTo(BeNil())
+				Expect(degraded.Reason).To(Equal(webappv1.ReasonObjectConflict))
+				Expect(degraded.Message).To(HavePrefix(kind + " " + name.String() + " already exists"))
+			}
+			manualService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: blueKey.Name, Namespace: blueKey.Namespace},
+				Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "manual"}},
+			}
+			Expect(reconciler.Create(ctx, manualService)).To(Succeed())
+
+			expectConflict(reconcileMyapp(), "Service", blueKey)
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, blueKey, &service)).To(Succeed())
+			Expect(service.OwnerReferences).To(BeEmpty())
+			Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "manual"}))
+
+			By("previewing a new image next to a preview route it does not control")
+			Expect(reconciler.Delete(ctx, manualService)).To(Succeed())
+			manualRoute := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: previewKey.Name, Namespace: previewKey.Namespace},
+			}
+			Expect(reconciler.Create(ctx, manualRoute)).To(Succeed())
+			reconcileMyapp()
+			markAvailable(blueKey)
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			expectConflict(reconcileMyapp(), "HTTPRoute", previewKey)
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, previewKey, &route)).To(Succeed())
+			Expect(route.OwnerReferences).To(BeEmpty())
+			Expect(route.Spec.Hostnames).To(BeEmpty())
+		})
+
+		It("should take over from the generated Deployment when promoted", func() {
+			rollout := myapp.Spec.Rollout
+			myapp.Spec.Rollout = nil
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			reconcileMyapp()
+			markAvailable(key)
+
+			By("enabling blue/green without autoPromote along with a new image")
+			rollout.BlueGreen.AutoPromote = ptr.To(false)
+			myapp.Spec.Rollout = rollout
+			myapp.Spec.Image = "tusova194/my_test_app:1.0.6"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			reconcileMyapp()
+			Expect(myapp.Status.BlueGreen).To(Equal(&webappv1.BlueGreenStatus{PreviewColor: webappv1.ColorBlue}))
+			Expect(getDeployment(key).Spec.Template.Spec.Containers[0].Image).To(Equal("tusova194/my_test_app:1.0.5"))
+			Expect(routeBackend(key)).To(Equal(key.Name))
+
+			By("waiting to be promoted")
+			markAvailable(blueKey)
+			reconcileMyapp()
+			Expect(myapp.Status.BlueGreen.PreviewColor).To(Equal(webappv1.ColorBlue))
+
+			myapp.Annotations = map[string]string{webappv1.PromoteAnnotation: ""}
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+			reconcileMyapp()
+			Expect(myapp.Annotations).NotTo(HaveKey(webappv1.PromoteAnnotation))
+			Expect(myapp.Status.BlueGreen.ActiveColor).To(Equal(webappv1.ColorBlue))
+			Expect(routeBackend(key)).To(Equal(blueKey.Name))
+
+			By("deleting the generated Deployment after the scale-down delay")
+			elapseScaleDownDelay()
+			reconcileMyapp()
+			Expect(errors.IsNotFound(reconciler.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+		})
+	})
+
+	Context("When mapping watched objects to Myapps", func() {
+		ctx := context.Background()
+
+		var reconciler *MyappReconciler
+
+		owned := func(obj metav1.Object, owner string) {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{
+				APIVersion: webappv1.GroupVersion.String(),
+				Kind:       "Myapp",
+				Name:       owner,
+				UID:        types.UID(owner + "-uid"),
+				Controller: ptr.To(true),
+			}})
+		}
+
+		serviceBackend := func(name string) gatewayv1.HTTPRouteRule {
+			return gatewayv1.HTTPRouteRule{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{
+							Name: gatewayv1.ObjectName(name),
+							Port: ptr.To(gatewayv1.PortNumber(80)),
+						},
+					},
+				}},
+			}
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			ownedService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"},
+			}
+			owned(ownedService, "frontend")
+
+			ownedRoute := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"},
+				Spec: gatewayv1.HTTPRouteSpec{
+					Rules: []gatewayv1.HTTPRouteRule{serviceBackend("frontend")},
+				},
+			}
+			owned(ownedRoute, "frontend")
+
+			manualRoute := &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "default"},
+				Spec: gatewayv1.HTTPRouteSpec{
+					Rules: []gatewayv1.HTTPRouteRule{serviceBackend("frontend"), serviceBackend("unmanaged")},
+				},
+			}
+
+			unmanagedService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"},
+			}
+
+			reconciler = &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ownedService, ownedRoute, manualRoute, unmanagedService).
+					Build(),
+				Scheme: scheme,
+			}
+		})
+
+		It("should map an owned Service to its Myapp", func() {
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "frontend", Namespace: "default"}, &service)).To(Succeed())
 
-			By("Cleanup the specific resource instance Myapp")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			Expect(reconciler.myappsForService(ctx, &service)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "frontend", Namespace: "default"},
+			}))
 		})
 
-		It("should successfully reconcile the Myapp resource", func() {
-			By("Reconciling the created Myapp resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+		It("should map a Service by its Myapp label", func() {
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "labelled",
+					Namespace: "default",
+					Labels:    map[string]string{myappLabel: "backend"},
+				},
 			}
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+			Expect(reconciler.myappsForService(ctx, service)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "backend", Namespace: "default"},
+			}))
+		})
+
+		It("should not map an unrelated Service", func() {
+			var service corev1.Service
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "unmanaged", Namespace: "default"}, &service)).To(Succeed())
+
+			Expect(reconciler.myappsForService(ctx, &service)).To(BeEmpty())
+		})
+
+		It("should map an HTTPRoute to the Myapps owning its backend Services", func() {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "manual", Namespace: "default"}, &route)).To(Succeed())
+
+			Expect(reconciler.myappsForRoute(ctx, &route)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "frontend", Namespace: "default"},
+			}))
+		})
+
+		It("should map an owned HTTPRoute once to its Myapp", func() {
+			var route gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "frontend", Namespace: "default"}, &route)).To(Succeed())
+
+			Expect(reconciler.myappsForRoute(ctx, &route)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "frontend", Namespace: "default"},
+			}))
+		})
+	})
+
+	Context("When checking HTTPRoute backendRefs", func() {
+		ctx := context.Background()
+
+		route := func(name string, backends ...gatewayv1.BackendObjectReference) gatewayv1.HTTPRoute {
+			rule := gatewayv1.HTTPRouteRule{}
+			for _, backend := range backends {
+				rule.BackendRefs = append(rule.BackendRefs, gatewayv1.HTTPBackendRef{
+					BackendRef: gatewayv1.BackendRef{BackendObjectReference: backend},
+				})
+			}
+			return gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec:       gatewayv1.HTTPRouteSpec{Rules: []gatewayv1.HTTPRouteRule{rule}},
+			}
+		}
+
+		backend := func(name string, port int32) gatewayv1.BackendObjectReference {
+			return gatewayv1.BackendObjectReference{
+				Name: gatewayv1.ObjectName(name),
+				Port: ptr.To(gatewayv1.PortNumber(port)),
+			}
+		}
+
+		services := []corev1.Service{{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-app-service", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}}
+
+		It("should accept backendRefs to existing Service ports", func() {
+			routes := []gatewayv1.HTTPRoute{route("my-app-route", backend("my-app-service", 80))}
+
+			Expect(checkBackendRefs(routeObjects(routes), services, nil)).To(BeEmpty())
+		})
+
+		It("should report backendRefs to missing Services and ports", func() {
+			routes := []gatewayv1.HTTPRoute{
+				route("my-app-route", backend("my-app-service", 8080), backend("missing", 80)),
+				route("no-port", gatewayv1.BackendObjectReference{Name: "my-app-service"}),
+			}
+
+			broken := checkBackendRefs(routeObjects(routes), services, nil)
+			Expect(broken).To(HaveLen(3))
+			Expect(broken[0].reason).To(Equal(webappv1.BackendReasonPortNotFound))
+			Expect(broken[0].port).To(Equal(int32(8080)))
+			Expect(broken[1].reason).To(Equal(webappv1.BackendReasonServiceNotFound))
+			Expect(broken[1].service.Name).To(Equal("missing"))
+			Expect(broken[2].reason).To(Equal(webappv1.BackendReasonPortNotFound))
+			Expect(broken[2].route.Name).To(Equal("no-port"))
+		})
+
+		It("should report broken backendRefs of GRPCRoutes with their kind", func() {
+			grpcRoute := gatewayv1.GRPCRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "grpc", Namespace: "default"},
+				Spec: gatewayv1.GRPCRouteSpec{Rules: []gatewayv1.GRPCRouteRule{{
+					BackendRefs: []gatewayv1.GRPCBackendRef{
+						{BackendRef: gatewayv1.BackendRef{BackendObjectReference: backend("my-app-service", 80)}},
+						{BackendRef: gatewayv1.BackendRef{BackendObjectReference: backend("missing", 80)}},
+					},
+				}}},
+			}
+			routes := []gatewayv1.HTTPRoute{route("grpc", backend("missing", 80))}
+
+			broken := checkBackendRefs(append(routeObjects(routes), &grpcRoute), services, nil)
+			Expect(broken).To(HaveLen(2))
+			Expect(broken[0].kind).To(Equal("HTTPRoute"))
+			Expect(broken[1].kind).To(Equal("GRPCRoute"))
+			Expect(broken[1].toStatus().Kind).To(Equal("GRPCRoute"))
+			Expect(broken[1].service.Name).To(Equal("missing"))
+		})
+
+		It("should ignore backendRefs to kinds other than Service", func() {
+			routes := []gatewayv1.HTTPRoute{route("other", gatewayv1.BackendObjectReference{
+				Group: ptr.To(gatewayv1.Group("example.com")),
+				Kind:  ptr.To(gatewayv1.Kind("Bucket")),
+				Name:  "static",
+			})}
+
+			Expect(checkBackendRefs(routeObjects(routes), nil, nil)).To(BeEmpty())
+		})
+
+		It("should attribute broken backendRefs to every Service generated for a Myapp", func() {
+			myapp := &webappv1.Myapp{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"}}
+			routes := routeObjects([]gatewayv1.HTTPRoute{
+				route("canary", backend("frontend-canary", 80)),
+				route("blue", backend("frontend-blue", 80)),
+				route("other", backend("backend", 80)),
 			})
+
+			relevant := brokenBackendRefsFor(myapp, routes, checkBackendRefs(routes, nil, nil))
+			Expect(relevant).To(HaveLen(2))
+			Expect(relevant[0].service.Name).To(Equal("frontend-canary"))
+			Expect(relevant[1].service.Name).To(Equal("frontend-blue"))
+		})
+
+		It("should report broken backendRefs to the referenced Myapp", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp := &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", UID: "frontend-uid"},
+				Spec:       webappv1.MyappSpec{Image: "tusova194/my_test_app:1.0.5", Port: 3002},
+			}
+			manualRoute := route("manual", backend("frontend", 80))
+
+			recorder := record.NewFakeRecorder(32)
+			reconciler := &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp, &manualRoute).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme:   scheme,
+				Recorder: recorder,
+			}
+
+			key := types.NamespacedName{Name: "frontend", Namespace: "default"}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionBackendRefsResolved)).To(BeTrue())
+			Expect(myapp.Status.BrokenBackendRefs).To(ConsistOf(HaveField("Route", "default/manual")))
+			Expect(myapp.Status.BrokenBackendRefs[0].Reason).To(Equal(webappv1.BackendReasonPortNotFound))
+
+			events := drainEvents(recorder)
+			Expect(events).To(ContainElements(
+				"Normal Created Created Service frontend",
+				"Normal Created Created for Myapp frontend",
+			))
+			// On the Myapp, the referenced Service and the route
+			Expect(events).To(ContainElements(
+				HavePrefix("Warning PortNotFound HTTPRoute default/manual rule 0: "),
+				HavePrefix("Warning PortNotFound HTTPRoute default/manual rule 0: "),
+				HavePrefix("Warning PortNotFound Rule 0: "),
+			))
+
+			By("reconciling again without changes")
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
 			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).NotTo(Receive())
 		})
 
-		It("should handle non-existent Myapp resource gracefully", func() {
-			By("Reconciling a non-existent Myapp resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+		It("should require a ReferenceGrant for backendRefs to other namespaces", func() {
+			crossNamespace := backend("payments", 80)
+			crossNamespace.Namespace = ptr.To(gatewayv1.Namespace("shared"))
+			routes := []gatewayv1.HTTPRoute{route("my-app-route", crossNamespace)}
+			shared := append(services, corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "shared"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+			})
+
+			broken := checkBackendRefs(routeObjects(routes), shared, nil)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.BackendReasonRefNotPermitted))
+
+			grant := gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "routes", Namespace: "shared"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "GRPCRoute", Namespace: "default"}},
+					To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service", Name: ptr.To(gatewayv1.ObjectName("payments"))}},
+				},
 			}
+			By("ignoring grants for other route kinds")
+			Expect(checkBackendRefs(routeObjects(routes), shared, []gatewayv1beta1.ReferenceGrant{grant})).To(HaveLen(1))
 
-			nonExistentName := types.NamespacedName{
-				Name:      "non-existent-resource",
-				Namespace: "default",
+			By("accepting grants for HTTPRoutes of the route namespace")
+			grant.Spec.From[0].Kind = "HTTPRoute"
+			Expect(checkBackendRefs(routeObjects(routes), shared, []gatewayv1beta1.ReferenceGrant{grant})).To(BeEmpty())
+
+			By("ignoring grants for other Services")
+			grant.Spec.To[0].Name = ptr.To(gatewayv1.ObjectName("billing"))
+			Expect(checkBackendRefs(routeObjects(routes), shared, []gatewayv1beta1.ReferenceGrant{grant})).To(HaveLen(1))
+		})
+
+		It("should route a Myapp to a Service in another namespace once granted", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp := &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", UID: "frontend-uid"},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Port:  3002,
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs:  []webappv1.ParentRef{{Name: "gateway"}},
+						BackendRefs: []webappv1.BackendRef{{Name: "payments", Namespace: "shared", Port: 80, Weight: ptr.To[int32](3)}},
+					},
+				},
+			}
+			payments := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "shared"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+			}
+			reconciler := &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp, payments).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(32),
 			}
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: nonExistentName,
+			key := types.NamespacedName{Name: "frontend", Namespace: "default"}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			var httpRoute gatewayv1.HTTPRoute
+			Expect(reconciler.Get(ctx, key, &httpRoute)).To(Succeed())
+			backendRefs := httpRoute.Spec.Rules[0].BackendRefs
+			Expect(backendRefs).To(HaveLen(2))
+			Expect(backendRefs[1].Namespace).To(HaveValue(Equal(gatewayv1.Namespace("shared"))))
+			Expect(backendRefs[1].Weight).To(HaveValue(Equal(int32(3))))
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.BrokenBackendRefs).To(ConsistOf(HaveField("Reason", webappv1.BackendReasonRefNotPermitted)))
+
+			By("granting HTTPRoutes of the Myapp namespace access to the Service")
+			grant := &gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "routes", Namespace: "shared"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "HTTPRoute", Namespace: "default"}},
+					To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service"}},
+				},
+			}
+			Expect(reconciler.Create(ctx, grant)).To(Succeed())
+			Expect(reconciler.myappsForReferenceGrant(ctx, grant)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+			Expect(reconciler.myappsForService(ctx, payments)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.BrokenBackendRefs).To(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionBackendRefsResolved)).To(BeTrue())
+		})
+
+		It("should read grants outside the watched namespaces from the API server", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp := &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", UID: "frontend-uid"},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Port:  3002,
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs:  []webappv1.ParentRef{{Name: "gateway"}},
+						BackendRefs: []webappv1.BackendRef{{Name: "payments", Namespace: "shared", Port: 80}},
+					},
+				},
+			}
+			payments := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "shared"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+			}
+			grant := &gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "routes", Namespace: "shared"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "HTTPRoute", Namespace: "default"}},
+					To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service"}},
+				},
+			}
+			apiServer := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(myapp, payments, grant).
+				WithStatusSubresource(&webappv1.Myapp{}).
+				Build()
+			// The cache only covers the default namespace
+			outsideCache := errors.NewBadRequest("unknown namespace for the cache")
+			cache := interceptor.NewClient(apiServer, interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+					opts ...client.GetOption) error {
+					if key.Namespace != "default" {
+						return outsideCache
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if (&client.ListOptions{}).ApplyOptions(opts).Namespace != "default" {
+						return outsideCache
+					}
+					return c.List(ctx, list, opts...)
+				},
 			})
+			reconciler := &MyappReconciler{
+				Client:    cache,
+				APIReader: apiServer,
+				Scheme:    scheme,
+				Recorder:  record.NewFakeRecorder(32),
+			}
+
+			key := types.NamespacedName{Name: "frontend", Namespace: "default"}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
 			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(myapp.Status.BrokenBackendRefs).To(BeEmpty())
+			Expect(reconciler.myappsForService(ctx, payments)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
 		})
 	})
 
-	Context("When reconciling an HTTPRoute resource", func() {
-		const httpRouteName = "test-httproute"
-
+	Context("When checking route parentRefs", func() {
 		ctx := context.Background()
 
-		httpRouteNamespacedName := types.NamespacedName{
-			Name:      httpRouteName,
-			Namespace: "default",
+		gateway := func(namespace string, listeners ...gatewayv1.Listener) gatewayv1.Gateway {
+			return gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: namespace},
+				Spec:       gatewayv1.GatewaySpec{GatewayClassName: "nginx", Listeners: listeners},
+			}
 		}
 
-		It("should handle HTTPRoute reconciliation (if Gateway API is available)", func() {
-			By("Attempting to reconcile an HTTPRoute resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+		listener := func(name string, protocol gatewayv1.ProtocolType, hostname string) gatewayv1.Listener {
+			l := gatewayv1.Listener{Name: gatewayv1.SectionName(name), Protocol: protocol, Port: 80}
+			if hostname != "" {
+				l.Hostname = ptr.To(gatewayv1.Hostname(hostname))
 			}
+			return l
+		}
 
-			// Try to reconcile an HTTPRoute - this should handle the case gracefully
-			// whether Gateway API is installed or not
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: httpRouteNamespacedName,
-			})
-			Expect(err).NotTo(HaveOccurred())
+		route := func(section string, hostnames ...gatewayv1.Hostname) *gatewayv1.HTTPRoute {
+			parentRef := gatewayv1.ParentReference{Name: "gateway"}
+			if section != "" {
+				parentRef.SectionName = ptr.To(gatewayv1.SectionName(section))
+			}
+			return &gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-app-route", Namespace: "default"},
+				Spec: gatewayv1.HTTPRouteSpec{
+					CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{parentRef}},
+					Hostnames:       hostnames,
+				},
+			}
+		}
+
+		objectsFor := func(gateways ...gatewayv1.Gateway) gatewayObjects {
+			objs := gatewayObjects{
+				gateways: make(map[types.NamespacedName]*gatewayv1.Gateway),
+				classes:  map[string]bool{"nginx": true},
+			}
+			for i := range gateways {
+				objs.gateways[client.ObjectKeyFromObject(&gateways[i])] = &gateways[i]
+			}
+			return objs
+		}
+
+		It("should accept routes attached to a matching listener", func() {
+			objs := objectsFor(gateway("default",
+				listener("http", gatewayv1.HTTPProtocolType, "*.my-apps.com"),
+				listener("tls", gatewayv1.TLSProtocolType, "")))
+
+			Expect(checkParentRefs([]client.Object{route("http", "test.my-apps.com")}, objs)).To(BeEmpty())
+			Expect(checkParentRefs([]client.Object{route("", "test.my-apps.com")}, objs)).To(BeEmpty())
 		})
 
-		It("should handle non-existent HTTPRoute resource gracefully", func() {
-			By("Reconciling a non-existent HTTPRoute resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+		It("should report missing Gateways, GatewayClasses and listeners", func() {
+			broken := checkParentRefs([]client.Object{route("http")}, objectsFor())
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.ParentReasonGatewayNotFound))
+			Expect(broken[0].toStatus()).To(Equal(webappv1.BrokenParentRef{
+				Kind:        "HTTPRoute",
+				Route:       "default/my-app-route",
+				Gateway:     "default/gateway",
+				SectionName: "http",
+				Reason:      webappv1.ParentReasonGatewayNotFound,
+				Message:     "Gateway default/gateway does not exist",
+			}))
+
+			objs := objectsFor(gateway("default", listener("https", gatewayv1.HTTPSProtocolType, "")))
+			broken = checkParentRefs([]client.Object{route("http")}, objs)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.ParentReasonNoMatchingParent))
+			Expect(broken[0].message).To(ContainSubstring("no listener named http"))
+
+			objs.classes = map[string]bool{}
+			broken = checkParentRefs([]client.Object{route("https")}, objs)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.ParentReasonGatewayClassNotFound))
+		})
+
+		It("should report listeners whose allowedRoutes do not admit the route", func() {
+			crossNamespace := route("http")
+			crossNamespace.Spec.ParentRefs[0].Namespace = ptr.To(gatewayv1.Namespace("infra"))
+			objs := objectsFor(gateway("infra", listener("http", gatewayv1.HTTPProtocolType, "")))
+
+			broken := checkParentRefs([]client.Object{crossNamespace}, objs)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.ParentReasonNotAllowedByListeners))
+			Expect(broken[0].gateway).To(Equal(types.NamespacedName{Namespace: "infra", Name: "gateway"}))
+
+			By("selecting the route namespace by label")
+			l := &objs.gateways[types.NamespacedName{Namespace: "infra", Name: "gateway"}].Spec.Listeners[0]
+			l.AllowedRoutes = &gatewayv1.AllowedRoutes{Namespaces: &gatewayv1.RouteNamespaces{
+				From:     ptr.To(gatewayv1.NamespacesFromSelector),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"gateway-access": "true"}},
+			}}
+			objs.namespaces = map[string]*corev1.Namespace{"default": {
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"gateway-access": "true"}},
+			}}
+			Expect(checkParentRefs([]client.Object{crossNamespace}, objs)).To(BeEmpty())
+
+			By("restricting the route kinds")
+			l.AllowedRoutes.Kinds = []gatewayv1.RouteGroupKind{{Kind: "GRPCRoute"}}
+			broken = checkParentRefs([]client.Object{crossNamespace}, objs)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].message).To(ContainSubstring("do not allow HTTPRoutes"))
+		})
+
+		It("should report routes whose hostnames match no listener", func() {
+			objs := objectsFor(gateway("default", listener("http", gatewayv1.HTTPProtocolType, "*.example.com")))
+
+			broken := checkParentRefs([]client.Object{route("http", "test.my-apps.com", "example.com")}, objs)
+			Expect(broken).To(HaveLen(1))
+			Expect(broken[0].reason).To(Equal(webappv1.ParentReasonNoMatchingListenerHostname))
+
+			Expect(hostnamesIntersect("*.example.com", "a.b.example.com")).To(BeTrue())
+			Expect(hostnamesIntersect("foo.example.com", "*.example.com")).To(BeTrue())
+			Expect(hostnamesIntersect("*.example.com", "*.foo.example.com")).To(BeTrue())
+			Expect(hostnamesIntersect("*.example.com", "example.com")).To(BeFalse())
+		})
+
+		It("should report broken parentRefs of a Myapp and map Gateway events to it", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+
+			myapp := &webappv1.Myapp{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", UID: "frontend-uid"},
+				Spec: webappv1.MyappSpec{
+					Image: "tusova194/my_test_app:1.0.5",
+					Port:  3002,
+					Routing: &webappv1.RoutingSpec{
+						ParentRefs: []webappv1.ParentRef{{Name: "gateway", SectionName: "http"}},
+						Hostnames:  []string{"test.my-apps.com"},
+					},
+				},
 			}
+			gw := gateway("default", listener("http", gatewayv1.HTTPProtocolType, "*.example.com"))
+			gatewayClass := &gatewayv1.GatewayClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}}
 
-			nonExistentName := types.NamespacedName{
-				Name:      "non-existent-httproute",
-				Namespace: "default",
+			recorder := record.NewFakeRecorder(32)
+			reconciler := &MyappReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(myapp, &gw, gatewayClass).
+					WithStatusSubresource(&webappv1.Myapp{}).
+					Build(),
+				Scheme:   scheme,
+				Recorder: recorder,
 			}
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: nonExistentName,
+			key := types.NamespacedName{Name: "frontend", Namespace: "default"}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(myapp.Status.Conditions, webappv1.ConditionParentRefsResolved)).To(BeTrue())
+			Expect(myapp.Status.BrokenParentRefs).To(ConsistOf(HaveField("Reason",
+				webappv1.ParentReasonNoMatchingListenerHostname)))
+			Expect(drainEvents(recorder)).To(ContainElement(
+				HavePrefix("Warning " + webappv1.ParentReasonNoMatchingListenerHostname)))
+
+			By("mapping the Gateway and its GatewayClass to the Myapp")
+			Expect(reconciler.myappsForGateway(ctx, &gw)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+			Expect(reconciler.myappsForGatewayClass(ctx, gatewayClass)).To(
+				ConsistOf(reconcile.Request{NamespacedName: key}))
+
+			By("fixing the listener hostname")
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(&gw), &gw)).To(Succeed())
+			gw.Spec.Listeners[0].Hostname = ptr.To(gatewayv1.Hostname("*.my-apps.com"))
+			Expect(reconciler.Update(ctx, &gw)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(myapp.Status.Conditions, webappv1.ConditionParentRefsResolved)).To(BeTrue())
+			Expect(myapp.Status.BrokenParentRefs).To(BeEmpty())
+
+			By("referencing a Gateway outside the watched namespaces")
+			reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+					opts ...client.GetOption) error {
+					if _, ok := obj.(*gatewayv1.Gateway); ok && key.Namespace == "infra" {
+						return errors.NewForbidden(gatewayv1.Resource("gateways"), key.Name, nil)
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
 			})
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			myapp.Spec.Routing.ParentRefs[0].Namespace = "infra"
+			Expect(reconciler.Update(ctx, myapp)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
 			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, myapp)).To(Succeed())
+			resolved := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionParentRefsResolved)
+			Expect(resolved).NotTo(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(resolved.Reason).To(Equal(webappv1.ReasonGatewayUnreadable))
+			Expect(resolved.Message).To(ContainSubstring("infra/gateway"))
 		})
 	})
 
-	Context("When reconciling a Service resource", func() {
-		const serviceName = "test-service"
-
+	Context("When filtering watched objects", func() {
 		ctx := context.Background()
 
-		serviceNamespacedName := types.NamespacedName{
-			Name:      serviceName,
-			Namespace: "default",
-		}
+		It("should parse selectors and filter events on labels", func() {
+			filter, err := ParseWatchFilter(managedLabel+"=true", "metadata.namespace!=kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter.IsEmpty()).To(BeFalse())
+			Expect(filter.ByObject().Field.String()).To(Equal("metadata.namespace!=kube-system"))
+
+			optedIn := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name: "opted-in", Labels: map[string]string{managedLabel: "true"},
+			}}
+			other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+			Expect(filter.predicate().Generic(event.GenericEvent{Object: optedIn})).To(BeTrue())
+			Expect(filter.predicate().Generic(event.GenericEvent{Object: other})).To(BeFalse())
+			Expect(WatchFilter{}.predicate().Generic(event.GenericEvent{Object: other})).To(BeTrue())
+
+			_, err = ParseWatchFilter("a b", "")
+			Expect(err).To(HaveOccurred())
+			_, err = ParseWatchFilter("", "a b")
+			Expect(err).To(HaveOccurred())
+		})
 
-		It("should handle Service reconciliation", func() {
-			By("Attempting to reconcile a Service resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
-			}
+		It("should reject label selectors that drop generated objects", func() {
+			filter, err := ParseWatchFilter("team=payments", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter.validate("Service")).To(MatchError(ContainSubstring(managedLabel)))
 
-			// Try to reconcile a Service - this should handle the case gracefully
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: serviceNamespacedName,
-			})
+			filter, err = ParseWatchFilter(managedLabel+"=true", "")
 			Expect(err).NotTo(HaveOccurred())
+			Expect(filter.validate("Service")).To(Succeed())
+			Expect(WatchFilter{}.validate("Service")).To(Succeed())
 		})
 
-		It("should handle non-existent Service resource gracefully", func() {
-			By("Reconciling a non-existent Service resource")
-			controllerReconciler := &MyappReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+		It("should read backend Services kept out of the cache", func() {
+			backend := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
 			}
+			routes := []gatewayv1.HTTPRoute{{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default"},
+				Spec: gatewayv1.HTTPRouteSpec{Rules: []gatewayv1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{
+							Name: "legacy", Port: ptr.To(gatewayv1.PortNumber(80)),
+						},
+					}}},
+				}}},
+			}}
 
-			nonExistentName := types.NamespacedName{
-				Name:      "non-existent-service",
-				Namespace: "default",
+			reconciler := &MyappReconciler{
+				APIReader: fake.NewClientBuilder().WithObjects(backend).Build(),
 			}
+			By("leaving the Services alone without a filter")
+			Expect(reconciler.readFilteredServices(ctx, routeObjects(routes), nil)).To(BeEmpty())
+
+			By("reading the missing backends with a filter")
+			reconciler.ServiceFilter, _ = ParseWatchFilter(managedLabel+"=true", "")
+			services := reconciler.readFilteredServices(ctx, routeObjects(routes), nil)
+			Expect(services).To(ConsistOf(HaveField("Name", "legacy")))
+			Expect(checkBackendRefs(routeObjects(routes), services, nil)).To(BeEmpty())
+		})
+	})
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: nonExistentName,
+	Context("When recording metrics", func() {
+		ctx := context.Background()
+
+		// histogramCount returns the number of reconciles observed for a trigger kind.
+		histogramCount := func(trigger string) uint64 {
+			var metric dto.Metric
+			Expect(reconcileDuration.WithLabelValues(trigger).(prometheus.Metric).Write(&metric)).To(Succeed())
+			return metric.GetHistogram().GetSampleCount()
+		}
+
+		It("should count broken and orphaned backendRefs per namespace", func() {
+			routes := []gatewayv1.HTTPRoute{{
+				ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "metrics"},
+				Spec: gatewayv1.HTTPRouteSpec{Rules: []gatewayv1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1.HTTPBackendRef{
+						{BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+							Name: "missing", Port: ptr.To(gatewayv1.PortNumber(80)),
+						}}},
+						{BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+							Name: "existing", Port: ptr.To(gatewayv1.PortNumber(81)),
+						}}},
+					},
+				}}},
+			}}
+			services := []corev1.Service{{
+				ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "metrics"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+			}}
+
+			recordNamespaceObjects("metrics", routeObjects(routes), services)
+			recordBrokenBackendRefs("metrics", routeObjects(routes), checkBackendRefs(routeObjects(routes), services, nil))
+
+			Expect(testutil.ToFloat64(httpRoutesTotal.WithLabelValues("metrics"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(servicesTotal.WithLabelValues("metrics"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(brokenBackendRefs.WithLabelValues("metrics", "HTTPRoute", "route"))).To(Equal(2.0))
+			Expect(testutil.ToFloat64(orphanedBackendRefs.WithLabelValues("metrics"))).To(Equal(1.0))
+		})
+
+		It("should count Myapps by their Ready condition", func() {
+			ready := func(status metav1.ConditionStatus) webappv1.Myapp {
+				return webappv1.Myapp{Status: webappv1.MyappStatus{Conditions: []metav1.Condition{{
+					Type: webappv1.ConditionReady, Status: status,
+				}}}}
+			}
+			recordMyapps("metrics", []webappv1.Myapp{
+				ready(metav1.ConditionTrue), ready(metav1.ConditionTrue), ready(metav1.ConditionFalse), {},
 			})
+
+			Expect(testutil.ToFloat64(myappsByReady.WithLabelValues("metrics", "True"))).To(Equal(2.0))
+			Expect(testutil.ToFloat64(myappsByReady.WithLabelValues("metrics", "False"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(myappsByReady.WithLabelValues("metrics", "Unknown"))).To(Equal(1.0))
+
+			By("deleting the series of the namespace once the Myapps are gone")
+			recordNamespaceObjects("metrics", nil, nil)
+			recordMyapps("metrics", nil)
+			namespace := prometheus.Labels{"namespace": "metrics"}
+			Expect(myappsByReady.DeletePartialMatch(namespace)).To(BeZero())
+			Expect(httpRoutesTotal.DeletePartialMatch(namespace)).To(BeZero())
+			Expect(servicesTotal.DeletePartialMatch(namespace)).To(BeZero())
+		})
+
+		It("should observe reconcile durations by the kind of object that triggered them", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+			Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+			reconciler := &MyappReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+				Scheme: scheme,
+			}
+			key := types.NamespacedName{Name: "triggered", Namespace: "metrics"}
+
+			serviceCount, unknownCount := histogramCount("Service"), histogramCount(unknownTrigger)
+
+			By("queueing the Myapp from a Service event")
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name: key.Name, Namespace: key.Namespace, Labels: map[string]string{myappLabel: key.Name},
+			}}
+			mapFunc := reconciler.triggers.mapFunc("Service", reconciler.myappsForService)
+			Expect(mapFunc(ctx, service)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(histogramCount("Service")).To(Equal(serviceCount + 1))
+
+			By("reconciling again without an event")
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
 			Expect(err).NotTo(HaveOccurred())
+			Expect(histogramCount("Service")).To(Equal(serviceCount + 1))
+			Expect(histogramCount(unknownTrigger)).To(Equal(unknownCount + 1))
+		})
+
+		It("should record the owning Myapp of a Deployment event as triggered by a Deployment", func() {
+			var triggers triggerKinds
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+				Name: "owned", Namespace: "metrics", Labels: map[string]string{myappLabel: "owner"},
+			}}
+			Expect(triggers.predicate("Deployment", owningMyapp).Generic(event.GenericEvent{Object: deployment})).
+				To(BeTrue())
+			Expect(triggers.take(types.NamespacedName{Name: "owner", Namespace: "metrics"})).To(Equal("Deployment"))
 		})
 	})
 
@@ -449,3 +2646,12 @@ var _ = Describe("Myapp Controller", func() {
 	})
 
 })
+
+// drainEvents returns the Events recorded so far.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	return events
+}
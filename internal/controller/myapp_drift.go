@@ -0,0 +1,416 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+const (
+	// handManagedAnnotation lists, comma-separated, the managed fields of a
+	// generated object that keep the value they were given by hand, such as
+	// spec.replicas for a Deployment scaled by an autoscaler.
+	handManagedAnnotation = "kontroller.my-apps.com/hand-managed-fields"
+	// appliedFieldsAnnotation holds a hash of the value of each managed field
+	// as the controller last wrote it, followed by a slash and a hash of the
+	// value read back when the API server added defaults to it. A field is
+	// only drift when its live value matches neither: it was then changed by
+	// hand rather than by a spec change or a rollout, which are written by the
+	// controller.
+	appliedFieldsAnnotation = "kontroller.my-apps.com/applied-fields"
+
+	reasonDrifted = "Drifted"
+)
+
+// managedField is a field of a generated object set from the Myapp.
+type managedField struct {
+	path string
+	// value returns the value of the field.
+	value func(obj client.Object) any
+	// keep copies the value of the field from live to desired.
+	keep func(desired, live client.Object)
+}
+
+// field returns a managed field at the given path of objects of type T.
+func field[T client.Object, V any](path string, ref func(T) *V) managedField {
+	return managedField{
+		path:  path,
+		value: func(obj client.Object) any { return *ref(obj.(T)) },
+		keep:  func(desired, live client.Object) { *ref(desired.(T)) = *ref(live.(T)) },
+	}
+}
+
+// labelsField returns the managed keys of a label map of objects of type T.
+// Labels with other keys are not managed.
+func labelsField[T client.Object](path string, ref func(T) *map[string]string, keys ...string) managedField {
+	return managedField{
+		path: path,
+		value: func(obj client.Object) any {
+			labels := make(map[string]string, len(keys))
+			for _, key := range keys {
+				if value, found := (*ref(obj.(T)))[key]; found {
+					labels[key] = value
+				}
+			}
+			return labels
+		},
+		keep: func(desired, live client.Object) {
+			labels := ref(desired.(T))
+			for _, key := range keys {
+				if value, found := (*ref(live.(T)))[key]; found {
+					*labels = mergeLabels(*labels, map[string]string{key: value})
+				} else {
+					delete(*labels, key)
+				}
+			}
+		},
+	}
+}
+
+// containerField returns a managed field of the application container of a
+// Deployment.
+func containerField[V any](name string, ref func(*corev1.Container) *V) managedField {
+	return managedField{
+		path: fmt.Sprintf("spec.template.spec.containers[%s].%s", containerName, name),
+		value: func(obj client.Object) any {
+			if container := appContainer(obj.(*appsv1.Deployment)); container != nil {
+				return *ref(container)
+			}
+			return nil
+		},
+		keep: func(desired, live client.Object) {
+			desiredContainer, liveContainer := appContainer(desired.(*appsv1.Deployment)), appContainer(live.(*appsv1.Deployment))
+			if desiredContainer != nil && liveContainer != nil {
+				*ref(desiredContainer) = *ref(liveContainer)
+			}
+		},
+	}
+}
+
+// appContainer returns the application container of a Deployment, if any.
+func appContainer(deployment *appsv1.Deployment) *corev1.Container {
+	for i := range deployment.Spec.Template.Spec.Containers {
+		if deployment.Spec.Template.Spec.Containers[i].Name == containerName {
+			return &deployment.Spec.Template.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// generatedLabelKeys are the keys of labelsFor, podLabelKeys those of podLabelsFor.
+var (
+	generatedLabelKeys = []string{nameLabel, myappLabel, managedByLabel, myappNamespaceLabel, managedLabel}
+	podLabelKeys       = []string{nameLabel, myappLabel, managedByLabel}
+)
+
+// deploymentFields are the fields of the generated Deployment set by mutateDeployment.
+var deploymentFields = []managedField{
+	labelsField("metadata.labels", func(d *appsv1.Deployment) *map[string]string { return &d.Labels },
+		generatedLabelKeys...),
+	field("spec.replicas", func(d *appsv1.Deployment) **int32 { return &d.Spec.Replicas }),
+	labelsField("spec.template.metadata.labels",
+		func(d *appsv1.Deployment) *map[string]string { return &d.Spec.Template.Labels }, podLabelKeys...),
+	containerField("image", func(c *corev1.Container) *string { return &c.Image }),
+	containerField("ports", func(c *corev1.Container) *[]corev1.ContainerPort { return &c.Ports }),
+	containerField("env", func(c *corev1.Container) *[]corev1.EnvVar { return &c.Env }),
+	containerField("resources", func(c *corev1.Container) *corev1.ResourceRequirements { return &c.Resources }),
+	containerField("livenessProbe", func(c *corev1.Container) **corev1.Probe { return &c.LivenessProbe }),
+	containerField("readinessProbe", func(c *corev1.Container) **corev1.Probe { return &c.ReadinessProbe }),
+}
+
+// serviceFields are the fields of the generated Service set by mutateService.
+var serviceFields = []managedField{
+	labelsField("metadata.labels", func(s *corev1.Service) *map[string]string { return &s.Labels },
+		generatedLabelKeys...),
+	field("spec.selector", func(s *corev1.Service) *map[string]string { return &s.Spec.Selector }),
+	field("spec.ports", func(s *corev1.Service) *[]corev1.ServicePort { return &s.Spec.Ports }),
+}
+
+// routeFields returns the fields of a generated route set by mutateRoute.
+func routeFields(route client.Object) []managedField {
+	switch route.(type) {
+	case *gatewayv1.HTTPRoute:
+		return []managedField{
+			labelsField("metadata.labels", func(r *gatewayv1.HTTPRoute) *map[string]string { return &r.Labels },
+				generatedLabelKeys...),
+			field("spec.parentRefs",
+				func(r *gatewayv1.HTTPRoute) *[]gatewayv1.ParentReference { return &r.Spec.ParentRefs }),
+			field("spec.hostnames", func(r *gatewayv1.HTTPRoute) *[]gatewayv1.Hostname { return &r.Spec.Hostnames }),
+			field("spec.rules", func(r *gatewayv1.HTTPRoute) *[]gatewayv1.HTTPRouteRule { return &r.Spec.Rules }),
+		}
+	case *gatewayv1.GRPCRoute:
+		return []managedField{
+			labelsField("metadata.labels", func(r *gatewayv1.GRPCRoute) *map[string]string { return &r.Labels },
+				generatedLabelKeys...),
+			field("spec.parentRefs",
+				func(r *gatewayv1.GRPCRoute) *[]gatewayv1.ParentReference { return &r.Spec.ParentRefs }),
+			field("spec.hostnames", func(r *gatewayv1.GRPCRoute) *[]gatewayv1.Hostname { return &r.Spec.Hostnames }),
+			field("spec.rules", func(r *gatewayv1.GRPCRoute) *[]gatewayv1.GRPCRouteRule { return &r.Spec.Rules }),
+		}
+	case *gatewayv1alpha2.TCPRoute:
+		return []managedField{
+			labelsField("metadata.labels", func(r *gatewayv1alpha2.TCPRoute) *map[string]string { return &r.Labels },
+				generatedLabelKeys...),
+			field("spec.parentRefs",
+				func(r *gatewayv1alpha2.TCPRoute) *[]gatewayv1.ParentReference { return &r.Spec.ParentRefs }),
+			field("spec.rules",
+				func(r *gatewayv1alpha2.TCPRoute) *[]gatewayv1alpha2.TCPRouteRule { return &r.Spec.Rules }),
+		}
+	case *gatewayv1alpha2.TLSRoute:
+		return []managedField{
+			labelsField("metadata.labels", func(r *gatewayv1alpha2.TLSRoute) *map[string]string { return &r.Labels },
+				generatedLabelKeys...),
+			field("spec.parentRefs",
+				func(r *gatewayv1alpha2.TLSRoute) *[]gatewayv1.ParentReference { return &r.Spec.ParentRefs }),
+			field("spec.hostnames",
+				func(r *gatewayv1alpha2.TLSRoute) *[]gatewayv1.Hostname { return &r.Spec.Hostnames }),
+			field("spec.rules",
+				func(r *gatewayv1alpha2.TLSRoute) *[]gatewayv1alpha2.TLSRouteRule { return &r.Spec.Rules }),
+		}
+	default:
+		return nil
+	}
+}
+
+// revertDrift compares the managed fields of a generated object as it was
+// read, live, with the desired values mutated into desired, and returns the
+// fields that drifted: those changed by hand since the controller last wrote
+// them. Writing desired reverts them and records the hashes of the desired
+// values in its applied-fields annotation. Hand-managed fields keep their
+// live value instead.
+func (r *MyappReconciler) revertDrift(live, desired client.Object, fields []managedField) []string {
+	exists := live.GetResourceVersion() != ""
+
+	handManaged, _ := handManagedFields(live, fields)
+
+	var applied map[string]string
+	if exists {
+		// A missing or malformed annotation only disables drift detection until the next write
+		_ = json.Unmarshal([]byte(live.GetAnnotations()[appliedFieldsAnnotation]), &applied)
+	}
+
+	hashes := make(map[string]string, len(fields))
+	var drifted []string
+	for _, f := range fields {
+		if handManaged[f.path] {
+			if exists {
+				f.keep(desired, live)
+			}
+			continue
+		}
+		value, desiredValue := f.value(live), f.value(desired)
+		hash := fieldHash(desiredValue)
+		hashes[f.path] = hash
+		previous, found := applied[f.path]
+		if !found {
+			continue
+		}
+		written, readBack, _ := strings.Cut(previous, "/")
+		switch liveHash := fieldHash(value); {
+		case liveHash == written || liveHash == readBack:
+		case readBack != "":
+			if !equality.Semantic.DeepEqual(value, desiredValue) {
+				drifted = append(drifted, f.path)
+			}
+		case written != hash:
+			// The previous value was not read back before the spec changed
+		case defaultedFrom(value, desiredValue):
+			// First read back since the value was written
+			readBack = liveHash
+		default:
+			drifted = append(drifted, f.path)
+		}
+		if written == hash && readBack != "" {
+			hashes[f.path] = written + "/" + readBack
+		}
+	}
+
+	// A map of strings always encodes
+	data, _ := json.Marshal(hashes)
+	annotations := desired.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[appliedFieldsAnnotation] = string(data)
+	desired.SetAnnotations(annotations)
+	return drifted
+}
+
+// defaultedFrom reports whether a live field value only adds values to the
+// desired one, as the API server does when it defaults fields.
+func defaultedFrom(live, desired any) bool {
+	var liveJSON, desiredJSON any
+	liveData, _ := json.Marshal(live)
+	desiredData, _ := json.Marshal(desired)
+	if json.Unmarshal(liveData, &liveJSON) != nil || json.Unmarshal(desiredData, &desiredJSON) != nil {
+		return false
+	}
+	return jsonDefaultedFrom(liveJSON, desiredJSON)
+}
+
+// jsonDefaultedFrom is defaultedFrom for decoded JSON values.
+func jsonDefaultedFrom(live, desired any) bool {
+	switch desired := desired.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		live, ok := live.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range desired {
+			if !jsonDefaultedFrom(live[key], value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		live, ok := live.([]any)
+		if !ok || len(live) != len(desired) {
+			return false
+		}
+		for i := range desired {
+			if !jsonDefaultedFrom(live[i], desired[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return live == desired
+	}
+}
+
+// handManagedFields returns the managed fields listed in the hand-managed
+// annotation of a generated object, and the listed paths that are not
+// managed fields.
+func handManagedFields(obj client.Object, fields []managedField) (map[string]bool, []string) {
+	handManaged := make(map[string]bool)
+	var unknown []string
+	for _, path := range strings.Split(obj.GetAnnotations()[handManagedAnnotation], ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if !slices.ContainsFunc(fields, func(f managedField) bool { return f.path == path }) {
+			unknown = append(unknown, path)
+			continue
+		}
+		handManaged[path] = true
+	}
+	return handManaged, unknown
+}
+
+// checkHandManagedFields reports the paths listed in the hand-managed
+// annotation of the generated objects that are not managed fields in the
+// HandManagedFieldsResolved condition. As the objects are checked on every
+// reconcile, Warning Events are only recorded when the condition changes.
+func (r *MyappReconciler) checkHandManagedFields(original, myapp *webappv1.Myapp, objs ...client.Object) {
+	type unknownField struct {
+		obj  client.Object
+		path string
+	}
+	var unknown []unknownField
+	var paths []string
+	for _, obj := range objs {
+		var fields []managedField
+		switch obj := obj.(type) {
+		case nil:
+			continue
+		case *appsv1.Deployment:
+			// Color Deployments are not checked
+			if obj.Name != myapp.Name {
+				continue
+			}
+			fields = deploymentFields
+		case *corev1.Service:
+			fields = serviceFields
+		default:
+			fields = routeFields(obj)
+		}
+		_, invalid := handManagedFields(obj, fields)
+		for _, path := range invalid {
+			unknown = append(unknown, unknownField{obj: obj, path: path})
+			paths = append(paths, fmt.Sprintf("%s %s %s", r.kindOf(obj), obj.GetName(), path))
+		}
+	}
+
+	if len(unknown) == 0 {
+		setCondition(myapp, webappv1.ConditionHandManagedFieldsResolved, metav1.ConditionTrue,
+			webappv1.ReasonResolved, "All hand-managed fields are managed fields")
+		return
+	}
+	message := fmt.Sprintf("Hand-managed fields are not managed fields: %s", strings.Join(paths, ", "))
+	setCondition(myapp, webappv1.ConditionHandManagedFieldsResolved, metav1.ConditionFalse,
+		webappv1.ReasonUnknownHandManagedField, message)
+	if resolved := meta.FindStatusCondition(original.Status.Conditions,
+		webappv1.ConditionHandManagedFieldsResolved); resolved != nil && resolved.Message == message {
+		return
+	}
+	for _, u := range unknown {
+		r.eventf(u.obj, corev1.EventTypeWarning, webappv1.ReasonUnknownHandManagedField,
+			"%s is not a field managed for Myapp %s", u.path, myapp.Name)
+	}
+}
+
+// fieldHash returns a short hash of the JSON encoding of a field value.
+func fieldHash(value any) string {
+	data, _ := json.Marshal(value)
+	hash := fnv.New32a()
+	_, _ = hash.Write(data)
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// recordDrift records the drifted fields of a generated object, just
+// reverted, in the Myapp status and as Warning Events on the Myapp and the object.
+func (r *MyappReconciler) recordDrift(myapp *webappv1.Myapp, obj client.Object, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	kind := r.kindOf(obj)
+	r.eventf(myapp, corev1.EventTypeWarning, reasonDrifted, "Reverted drift of %s %s: %s",
+		kind, obj.GetName(), strings.Join(fields, ", "))
+	r.eventf(obj, corev1.EventTypeWarning, reasonDrifted, "Reverted drift of %s for Myapp %s",
+		strings.Join(fields, ", "), myapp.Name)
+
+	drifted := webappv1.DriftedObject{Kind: kind, Name: obj.GetName(), Fields: fields, LastDetectedTime: metav1.Now()}
+	for i := range myapp.Status.Drift {
+		if myapp.Status.Drift[i].Kind == kind && myapp.Status.Drift[i].Name == obj.GetName() {
+			// The time only moves when other fields drift, so that the status is
+			// not rewritten for every revert of the same fields
+			if slices.Equal(myapp.Status.Drift[i].Fields, fields) {
+				drifted.LastDetectedTime = myapp.Status.Drift[i].LastDetectedTime
+			}
+			myapp.Status.Drift[i] = drifted
+			return
+		}
+	}
+	myapp.Status.Drift = append(myapp.Status.Drift, drifted)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// Reasons of the Events recorded on Myapps and the objects generated for them,
+// besides the reasons of broken references and rollouts.
+const (
+	reasonCreated         = "Created"
+	reasonUpdated         = "Updated"
+	reasonDeleted         = "Deleted"
+	reasonReconcileFailed = "ReconcileFailed"
+)
+
+// eventf records an Event on obj, when the reconciler has a Recorder.
+func (r *MyappReconciler) eventf(obj runtime.Object, eventType, reason, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// recordWrite records the creation or update of an object generated for the
+// Myapp, on the Myapp and on the object itself. Nothing is recorded when the
+// object was left unchanged.
+func (r *MyappReconciler) recordWrite(myapp *webappv1.Myapp, obj client.Object, op controllerutil.OperationResult) {
+	var reason, verb string
+	switch op {
+	case controllerutil.OperationResultCreated:
+		reason, verb = reasonCreated, "Created"
+	case controllerutil.OperationResultUpdated:
+		reason, verb = reasonUpdated, "Updated"
+	default:
+		return
+	}
+	kind := r.kindOf(obj)
+	r.eventf(myapp, corev1.EventTypeNormal, reason, "%s %s %s", verb, kind, obj.GetName())
+	r.eventf(obj, corev1.EventTypeNormal, reason, "%s for Myapp %s", verb, myapp.Name)
+}
+
+// recordDelete records on the Myapp the deletion of an object generated for it.
+func (r *MyappReconciler) recordDelete(myapp *webappv1.Myapp, obj client.Object) {
+	r.eventf(myapp, corev1.EventTypeNormal, reasonDeleted, "Deleted %s %s", r.kindOf(obj), obj.GetName())
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+const (
+	// myappFinalizer holds a Myapp until the objects generated for it are deleted.
+	myappFinalizer = "kontroller.my-apps.com/cleanup"
+
+	// cleanupRequeueInterval is how often cleanup is retried while generated
+	// objects are still being deleted.
+	cleanupRequeueInterval = 5 * time.Second
+)
+
+// generatedObjectLists returns empty lists of every kind the controller
+// generates for a Myapp.
+func generatedObjectLists() []client.ObjectList {
+	return []client.ObjectList{
+		&appsv1.DeploymentList{},
+		&corev1.ServiceList{},
+		&gatewayv1.HTTPRouteList{},
+		&gatewayv1.GRPCRouteList{},
+		&gatewayv1alpha2.TCPRouteList{},
+		&gatewayv1alpha2.TLSRouteList{},
+	}
+}
+
+// isGeneratedFor reports whether an object was generated for the Myapp: it
+// carries the labels of the Myapp or, in the Myapp namespace, is controlled by
+// it. Objects in other namespaces cannot carry an owner reference.
+func isGeneratedFor(obj client.Object, myapp *webappv1.Myapp) bool {
+	if obj.GetLabels()[myappLabel] != myapp.Name {
+		return false
+	}
+	if obj.GetLabels()[myappNamespaceLabel] == myapp.Namespace {
+		return true
+	}
+	return obj.GetNamespace() == myapp.Namespace && metav1.IsControlledBy(obj, myapp)
+}
+
+// finalize deletes the objects generated for a Myapp being deleted and releases
+// the Myapp once none of them is left, reporting progress in its status.
+func (r *MyappReconciler) finalize(ctx context.Context, myapp *webappv1.Myapp) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(myapp, myappFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	original := myapp.DeepCopy()
+
+	remaining, err := r.deleteGeneratedObjects(ctx, myapp)
+	if err != nil {
+		logger.Error(err, "Failed to delete generated objects")
+		return ctrl.Result{}, r.reportFailure(ctx, original, myapp, err)
+	}
+
+	if remaining > 0 {
+		logger.Info("Waiting for generated objects to be deleted", "remaining", remaining)
+		setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonDeleting,
+			fmt.Sprintf("Waiting for %d generated objects to be deleted", remaining))
+		if err := r.updateStatus(ctx, original, myapp); err != nil {
+			logger.Error(err, "Failed to update Myapp status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: cleanupRequeueInterval}, nil
+	}
+
+	logger.Info("Generated objects deleted, removing finalizer")
+	controllerutil.RemoveFinalizer(myapp, myappFinalizer)
+	if err := r.Update(ctx, myapp); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// deleteGeneratedObjects deletes the objects generated for the Myapp in every
+// namespace and returns how many of them are still present, for instance
+// because they are held by their own finalizers.
+func (r *MyappReconciler) deleteGeneratedObjects(ctx context.Context, myapp *webappv1.Myapp) (int, error) {
+	logger := logf.FromContext(ctx)
+
+	remaining := 0
+	for _, list := range generatedObjectLists() {
+		objs, err := r.listGeneratedObjects(ctx, myapp, list)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return 0, fmt.Errorf("failed to list generated objects: %w", err)
+		}
+
+		for _, obj := range objs {
+			if !isGeneratedFor(obj, myapp) {
+				continue
+			}
+			if obj.GetDeletionTimestamp() == nil {
+				err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+				if client.IgnoreNotFound(err) != nil {
+					return 0, fmt.Errorf("failed to delete %s %s/%s: %w", r.kindOf(obj), obj.GetNamespace(), obj.GetName(), err)
+				}
+				logger.Info("Deleted generated object", "kind", r.kindOf(obj),
+					"namespace", obj.GetNamespace(), "name", obj.GetName())
+			}
+			// Objects with finalizers outlive the delete call
+			if obj.GetDeletionTimestamp() != nil || len(obj.GetFinalizers()) > 0 {
+				remaining++
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// listGeneratedObjects lists the objects of a kind that may have been
+// generated for the Myapp: those of its namespace with its name label, which
+// include objects that lost their namespace label, and those of other
+// namespaces with both its name and namespace labels.
+func (r *MyappReconciler) listGeneratedObjects(
+	ctx context.Context, myapp *webappv1.Myapp, list client.ObjectList,
+) ([]client.Object, error) {
+	if err := r.List(ctx, list, client.InNamespace(myapp.Namespace),
+		client.MatchingLabels{myappLabel: myapp.Name}); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	var objs []client.Object
+	for _, item := range items {
+		if obj, ok := item.(client.Object); ok {
+			objs = append(objs, obj)
+		}
+	}
+
+	// A new list, as decoding may reuse the items of the first one
+	crossNamespace := list.DeepCopyObject().(client.ObjectList)
+	if err := r.List(ctx, crossNamespace,
+		client.MatchingLabels{myappLabel: myapp.Name, myappNamespaceLabel: myapp.Namespace}); err != nil {
+		return nil, err
+	}
+	if items, err = meta.ExtractList(crossNamespace); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if obj, ok := item.(client.Object); ok && obj.GetNamespace() != myapp.Namespace {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+// kindOf returns the kind of an object for logs and messages.
+func (r *MyappReconciler) kindOf(obj client.Object) string {
+	if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+		return gvk.Kind
+	}
+	return fmt.Sprintf("%T", obj)
+}
@@ -0,0 +1,396 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+const (
+	// myappLabel is set on every object generated for a Myapp and holds the Myapp name.
+	myappLabel = "kontroller.my-apps.com/myapp"
+	// myappNamespaceLabel is set on every object generated for a Myapp and holds
+	// the Myapp namespace, so that objects owner references cannot cover are found on cleanup.
+	myappNamespaceLabel = "kontroller.my-apps.com/myapp-namespace"
+
+	// managedLabel opts an object in to the controller watches when they are
+	// filtered by label. It is set on every object generated for a Myapp.
+	managedLabel = "kontroller.my-apps.com/managed"
+
+	nameLabel      = "app.kubernetes.io/name"
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "kontroller"
+
+	// containerName is the name of the application container in the generated Deployment.
+	containerName = "app"
+	// portName is the name of the container and Service port serving the application.
+	portName = "http"
+	// h2cAppProtocol tells Gateways to speak HTTP/2 over cleartext to the
+	// Service of a Myapp routed with gRPC.
+	h2cAppProtocol = "kubernetes.io/h2c"
+
+	// maxBackendWeight is the highest backendRef weight the Gateway API accepts.
+	maxBackendWeight int32 = 1000000
+)
+
+// selectorLabels returns the labels used to select the Pods of a Myapp.
+func selectorLabels(myapp *webappv1.Myapp) map[string]string {
+	return map[string]string{
+		nameLabel:  myapp.Name,
+		myappLabel: myapp.Name,
+	}
+}
+
+// labelsFor returns the labels set on every object generated for a Myapp.
+func labelsFor(myapp *webappv1.Myapp) map[string]string {
+	labels := selectorLabels(myapp)
+	labels[managedByLabel] = managedByValue
+	labels[myappNamespaceLabel] = myapp.Namespace
+	labels[managedLabel] = "true"
+	return labels
+}
+
+// podLabelsFor returns the labels set on the Pods of a Myapp.
+func podLabelsFor(myapp *webappv1.Myapp) map[string]string {
+	labels := selectorLabels(myapp)
+	labels[managedByLabel] = managedByValue
+	return labels
+}
+
+// mergeLabels sets the given labels on top of the existing ones, so that labels
+// added by other actors survive an update.
+func mergeLabels(existing, labels map[string]string) map[string]string {
+	if existing == nil {
+		existing = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		existing[k] = v
+	}
+	return existing
+}
+
+// replicasFor returns the desired replica count of a Myapp, applying the default.
+func replicasFor(myapp *webappv1.Myapp) int32 {
+	if myapp.Spec.Replicas == nil {
+		return webappv1.DefaultReplicas
+	}
+	return *myapp.Spec.Replicas
+}
+
+// portFor returns the application port of a Myapp, applying the default.
+func portFor(myapp *webappv1.Myapp) int32 {
+	if myapp.Spec.Port == 0 {
+		return webappv1.DefaultPort
+	}
+	return myapp.Spec.Port
+}
+
+// mutateDeployment sets the fields of the Deployment managed by the Myapp.
+// Fields not owned by the controller, such as those defaulted by the API server,
+// are left untouched so that an unchanged Myapp does not cause an update.
+func mutateDeployment(myapp *webappv1.Myapp, deployment *appsv1.Deployment) {
+	mutatePods(myapp, deployment, replicasFor(myapp), selectorLabels(myapp), podLabelsFor(myapp))
+}
+
+// mutatePods sets the fields of a Deployment running the Myapp application
+// container with the given replicas, selector and Pod labels.
+func mutatePods(
+	myapp *webappv1.Myapp, deployment *appsv1.Deployment, replicas int32, selector, podLabels map[string]string,
+) {
+	deployment.Labels = mergeLabels(deployment.Labels, labelsFor(myapp))
+	deployment.Spec.Replicas = ptr.To(replicas)
+	// The selector is immutable, so it is only set when the Deployment is created.
+	if deployment.Spec.Selector == nil {
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+	}
+	deployment.Spec.Template.Labels = mergeLabels(deployment.Spec.Template.Labels, podLabels)
+
+	podSpec := &deployment.Spec.Template.Spec
+	var container *corev1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			container = &podSpec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{Name: containerName})
+		container = &podSpec.Containers[len(podSpec.Containers)-1]
+	}
+
+	container.Image = myapp.Spec.Image
+	container.Ports = []corev1.ContainerPort{{
+		Name:          portName,
+		ContainerPort: portFor(myapp),
+		Protocol:      corev1.ProtocolTCP,
+	}}
+	container.Env = myapp.Spec.Env
+	container.Resources = myapp.Spec.Resources
+	container.LivenessProbe = probeFor(myapp.Spec.LivenessProbe)
+	container.ReadinessProbe = probeFor(myapp.Spec.ReadinessProbe)
+}
+
+// probeFor returns a copy of a Myapp probe with the values the API server
+// would default set explicitly, so that an unchanged Myapp does not cause an update.
+func probeFor(probe *corev1.Probe) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+	probe = probe.DeepCopy()
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = 1
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = 10
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = 1
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = 3
+	}
+	if probe.HTTPGet != nil && probe.HTTPGet.Scheme == "" {
+		probe.HTTPGet.Scheme = corev1.URISchemeHTTP
+	}
+	return probe
+}
+
+// mutateService sets the fields of the Service managed by the Myapp.
+func mutateService(myapp *webappv1.Myapp, service *corev1.Service) {
+	service.Labels = mergeLabels(service.Labels, labelsFor(myapp))
+	service.Spec.Selector = selectorLabels(myapp)
+	service.Spec.Ports = []corev1.ServicePort{{
+		Name:       portName,
+		Port:       portFor(myapp),
+		TargetPort: intstr.FromString(portName),
+		Protocol:   corev1.ProtocolTCP,
+	}}
+	if routing := myapp.Spec.Routing; routing != nil && protocolFor(routing) == webappv1.RouteProtocolGRPC {
+		service.Spec.Ports[0].AppProtocol = ptr.To(h2cAppProtocol)
+	}
+}
+
+// pathMatchesFor returns the path matches of a Myapp routing spec, applying the default.
+func pathMatchesFor(routing *webappv1.RoutingSpec) []webappv1.PathMatch {
+	if len(routing.Paths) == 0 {
+		return []webappv1.PathMatch{{Type: webappv1.PathMatchPathPrefix, Value: "/"}}
+	}
+	return routing.Paths
+}
+
+// parentRefsFor returns the Gateway parentRefs of a Myapp routing spec.
+func parentRefsFor(routing *webappv1.RoutingSpec) []gatewayv1.ParentReference {
+	parentRefs := make([]gatewayv1.ParentReference, 0, len(routing.ParentRefs))
+	for _, ref := range routing.ParentRefs {
+		parentRef := gatewayv1.ParentReference{
+			Group: ptr.To(gatewayv1.Group(gatewayv1.GroupName)),
+			Kind:  ptr.To(gatewayv1.Kind("Gateway")),
+			Name:  gatewayv1.ObjectName(ref.Name),
+		}
+		if ref.Namespace != "" {
+			parentRef.Namespace = ptr.To(gatewayv1.Namespace(ref.Namespace))
+		}
+		if ref.SectionName != "" {
+			parentRef.SectionName = ptr.To(gatewayv1.SectionName(ref.SectionName))
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+	return parentRefs
+}
+
+// hostnamesFor returns the hostnames of a Myapp routing spec.
+func hostnamesFor(routing *webappv1.RoutingSpec) []gatewayv1.Hostname {
+	hostnames := make([]gatewayv1.Hostname, 0, len(routing.Hostnames))
+	for _, hostname := range routing.Hostnames {
+		hostnames = append(hostnames, gatewayv1.Hostname(hostname))
+	}
+	return hostnames
+}
+
+// serviceBackendRef returns a reference to the Service serving a Myapp.
+func serviceBackendRef(myapp *webappv1.Myapp) gatewayv1.BackendRef {
+	return gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{
+			Group: ptr.To(gatewayv1.Group("")),
+			Kind:  ptr.To(gatewayv1.Kind("Service")),
+			Name:  gatewayv1.ObjectName(servingServiceName(myapp)),
+			Port:  ptr.To(gatewayv1.PortNumber(portFor(myapp))),
+		},
+		Weight: ptr.To[int32](1),
+	}
+}
+
+// backendRefsFor returns the backendRefs of the route generated for a Myapp:
+// the Service serving it followed by the additional backendRefs of its routing spec.
+// During a canary rollout, the canary Service follows the Myapp Service and
+// the weight of the Myapp Service is split between them; the other weights are
+// scaled so that they keep their share of the traffic.
+func backendRefsFor(myapp *webappv1.Myapp) []gatewayv1.BackendRef {
+	backendRefs := []gatewayv1.BackendRef{serviceBackendRef(myapp)}
+	scale := int32(1)
+	if canary := myapp.Status.Canary; canaryActive(canary) {
+		scale = 100
+		backendRefs[0].Weight = ptr.To(100 - canary.Weight)
+		canaryRef := serviceBackendRef(myapp)
+		canaryRef.Name = gatewayv1.ObjectName(myapp.CanaryName())
+		canaryRef.Weight = ptr.To(canary.Weight)
+		backendRefs = append(backendRefs, canaryRef)
+	}
+	for _, ref := range myapp.Spec.Routing.BackendRefs {
+		backendRef := gatewayv1.BackendRef{
+			BackendObjectReference: gatewayv1.BackendObjectReference{
+				Group: ptr.To(gatewayv1.Group("")),
+				Kind:  ptr.To(gatewayv1.Kind("Service")),
+				Name:  gatewayv1.ObjectName(ref.Name),
+				Port:  ptr.To(gatewayv1.PortNumber(ref.Port)),
+			},
+			Weight: ptr.To(scale),
+		}
+		if ref.Namespace != "" && ref.Namespace != myapp.Namespace {
+			backendRef.Namespace = ptr.To(gatewayv1.Namespace(ref.Namespace))
+		}
+		if ref.Weight != nil {
+			backendRef.Weight = ptr.To(min(*ref.Weight*scale, maxBackendWeight))
+		}
+		backendRefs = append(backendRefs, backendRef)
+	}
+	return backendRefs
+}
+
+// mutateHTTPRoute sets the fields of the HTTPRoute managed by the Myapp.
+// Values the Gateway API CRDs would default are set explicitly so that an
+// unchanged Myapp does not cause an update.
+func mutateHTTPRoute(myapp *webappv1.Myapp, route *gatewayv1.HTTPRoute) {
+	routing := myapp.Spec.Routing
+	route.Labels = mergeLabels(route.Labels, labelsFor(myapp))
+	route.Spec.ParentRefs = parentRefsFor(routing)
+	route.Spec.Hostnames = hostnamesFor(routing)
+
+	paths := pathMatchesFor(routing)
+	matches := make([]gatewayv1.HTTPRouteMatch, 0, len(paths))
+	for _, path := range paths {
+		matchType := gatewayv1.PathMatchPathPrefix
+		if path.Type != "" {
+			matchType = gatewayv1.PathMatchType(path.Type)
+		}
+		matches = append(matches, gatewayv1.HTTPRouteMatch{
+			Path: &gatewayv1.HTTPPathMatch{
+				Type:  ptr.To(matchType),
+				Value: ptr.To(path.Value),
+			},
+		})
+	}
+
+	var backendRefs []gatewayv1.HTTPBackendRef
+	for _, backendRef := range backendRefsFor(myapp) {
+		backendRefs = append(backendRefs, gatewayv1.HTTPBackendRef{BackendRef: backendRef})
+	}
+	route.Spec.Rules = []gatewayv1.HTTPRouteRule{{
+		Matches:     matches,
+		BackendRefs: backendRefs,
+	}}
+}
+
+// mutateGRPCRoute sets the fields of the GRPCRoute managed by the Myapp. Like
+// for HTTPRoutes, values the CRD would default are set explicitly.
+func mutateGRPCRoute(myapp *webappv1.Myapp, route *gatewayv1.GRPCRoute) {
+	routing := myapp.Spec.Routing
+	route.Labels = mergeLabels(route.Labels, labelsFor(myapp))
+	route.Spec.ParentRefs = parentRefsFor(routing)
+	route.Spec.Hostnames = hostnamesFor(routing)
+
+	// A rule without matches routes every service and method
+	var matches []gatewayv1.GRPCRouteMatch
+	for _, method := range routing.Methods {
+		matchType := gatewayv1.GRPCMethodMatchExact
+		if method.Type != "" {
+			matchType = gatewayv1.GRPCMethodMatchType(method.Type)
+		}
+		match := &gatewayv1.GRPCMethodMatch{Type: ptr.To(matchType)}
+		if method.Service != "" {
+			match.Service = ptr.To(method.Service)
+		}
+		if method.Method != "" {
+			match.Method = ptr.To(method.Method)
+		}
+		matches = append(matches, gatewayv1.GRPCRouteMatch{Method: match})
+	}
+
+	var backendRefs []gatewayv1.GRPCBackendRef
+	for _, backendRef := range backendRefsFor(myapp) {
+		backendRefs = append(backendRefs, gatewayv1.GRPCBackendRef{BackendRef: backendRef})
+	}
+	route.Spec.Rules = []gatewayv1.GRPCRouteRule{{
+		Matches:     matches,
+		BackendRefs: backendRefs,
+	}}
+}
+
+// mutateTCPRoute sets the fields of the TCPRoute managed by the Myapp. TCP
+// routes match on the Gateway listener only, so they have no hostnames.
+func mutateTCPRoute(myapp *webappv1.Myapp, route *gatewayv1alpha2.TCPRoute) {
+	route.Labels = mergeLabels(route.Labels, labelsFor(myapp))
+	route.Spec.ParentRefs = parentRefsFor(myapp.Spec.Routing)
+	route.Spec.Rules = []gatewayv1alpha2.TCPRouteRule{{
+		BackendRefs: backendRefsFor(myapp),
+	}}
+}
+
+// mutateTLSRoute sets the fields of the TLSRoute managed by the Myapp, which
+// matches connections on their SNI hostname.
+func mutateTLSRoute(myapp *webappv1.Myapp, route *gatewayv1alpha2.TLSRoute) {
+	route.Labels = mergeLabels(route.Labels, labelsFor(myapp))
+	route.Spec.ParentRefs = parentRefsFor(myapp.Spec.Routing)
+	route.Spec.Hostnames = hostnamesFor(myapp.Spec.Routing)
+	route.Spec.Rules = []gatewayv1alpha2.TLSRouteRule{{
+		BackendRefs: backendRefsFor(myapp),
+	}}
+}
+
+// generatedObjects returns the Deployment, the Service and, with a routing
+// section, the route the controller generates for a Myapp when no rollout is
+// in progress, controlled by the Myapp.
+func generatedObjects(myapp *webappv1.Myapp) []client.Object {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: myapp.Name, Namespace: myapp.Namespace}}
+	mutateDeployment(myapp, deployment)
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: myapp.Name, Namespace: myapp.Namespace}}
+	mutateService(myapp, service)
+	objs := []client.Object{deployment, service}
+
+	if myapp.Spec.Routing != nil {
+		route := newRoute(protocolFor(myapp.Spec.Routing))
+		route.SetName(myapp.Name)
+		route.SetNamespace(myapp.Namespace)
+		mutateRoute(myapp, route)
+		objs = append(objs, route)
+	}
+
+	owner := metav1.NewControllerRef(myapp, webappv1.GroupVersion.WithKind("Myapp"))
+	for _, obj := range objs {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return objs
+}
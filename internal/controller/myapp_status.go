@@ -0,0 +1,194 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// setCondition sets a condition on the Myapp status, stamped with the Myapp generation.
+func setCondition(myapp *webappv1.Myapp, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&myapp.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: myapp.Generation,
+	})
+}
+
+// setFailedConditions records a reconcile error on the Myapp status.
+func setFailedConditions(myapp *webappv1.Myapp, err error) {
+	setCondition(myapp, webappv1.ConditionDegraded, metav1.ConditionTrue, webappv1.ReasonReconcileFailed, err.Error())
+	setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonReconcileFailed, err.Error())
+}
+
+// setRouteKindMissingConditions records that the route kind selected by the
+// Myapp routing protocol is not installed in the cluster.
+func setRouteKindMissingConditions(myapp *webappv1.Myapp, kind string) {
+	message := fmt.Sprintf("The %s CRD is not installed, install the experimental Gateway API channel", kind)
+	setCondition(myapp, webappv1.ConditionDegraded, metav1.ConditionTrue, webappv1.ReasonRouteKindNotInstalled, message)
+	setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonRouteKindNotInstalled, message)
+	meta.RemoveStatusCondition(&myapp.Status.Conditions, webappv1.ConditionRouteAccepted)
+}
+
+// setConditions computes the Myapp conditions from its generated Deployment and
+// route. route is nil when the Myapp has no routing section.
+func setConditions(myapp *webappv1.Myapp, deployment *appsv1.Deployment, route client.Object) {
+	available, rolloutMessage := deploymentAvailable(myapp, deployment)
+	if available {
+		setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionFalse, webappv1.ReasonRolloutComplete,
+			"Deployment rollout is complete")
+	} else {
+		setCondition(myapp, webappv1.ConditionProgressing, metav1.ConditionTrue, webappv1.ReasonRollingOut, rolloutMessage)
+	}
+
+	if reason, message, degraded := deploymentDegraded(deployment); degraded {
+		setCondition(myapp, webappv1.ConditionDegraded, metav1.ConditionTrue, reason, message)
+	} else {
+		setCondition(myapp, webappv1.ConditionDegraded, metav1.ConditionFalse, webappv1.ReasonAsExpected,
+			"Myapp reconciled successfully")
+	}
+
+	routeAccepted := true
+	if route == nil {
+		meta.RemoveStatusCondition(&myapp.Status.Conditions, webappv1.ConditionRouteAccepted)
+	} else {
+		status, reason, message := routeAcceptance(route)
+		setCondition(myapp, webappv1.ConditionRouteAccepted, status, reason, message)
+		routeAccepted = status == metav1.ConditionTrue
+	}
+
+	switch {
+	case !available:
+		setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonUnavailable, rolloutMessage)
+	case !routeAccepted:
+		setCondition(myapp, webappv1.ConditionReady, metav1.ConditionFalse, webappv1.ReasonNotAccepted,
+			routeKindOf(route)+" is not accepted by all of its Gateways")
+	default:
+		setCondition(myapp, webappv1.ConditionReady, metav1.ConditionTrue, webappv1.ReasonAvailable,
+			"Myapp is available")
+	}
+}
+
+// setWorkloadStatus records the image, replica counts and URL of the Myapp
+// workload, as shown by kubectl get.
+func setWorkloadStatus(myapp *webappv1.Myapp, deployment *appsv1.Deployment, service *corev1.Service) {
+	myapp.Status.Image = ""
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == containerName {
+			myapp.Status.Image = container.Image
+		}
+	}
+	myapp.Status.Replicas = deployment.Status.Replicas
+	myapp.Status.ReadyReplicas = deployment.Status.ReadyReplicas
+	myapp.Status.URL = urlFor(myapp, service)
+}
+
+// urlFor returns the address the Myapp is served at: its first non-wildcard
+// hostname, and first path for HTTP, when it is routed, the cluster address of
+// its Service otherwise. Routes without a usable hostname, such as TCP routes,
+// have no URL.
+func urlFor(myapp *webappv1.Myapp, service *corev1.Service) string {
+	routing := myapp.Spec.Routing
+	if routing == nil {
+		return fmt.Sprintf("http://%s.%s.svc:%d", service.Name, service.Namespace, portFor(myapp))
+	}
+	if protocolFor(routing) == webappv1.RouteProtocolTCP {
+		return ""
+	}
+	for _, hostname := range routing.Hostnames {
+		if strings.HasPrefix(hostname, "*") {
+			continue
+		}
+		switch protocolFor(routing) {
+		case webappv1.RouteProtocolGRPC:
+			return "grpc://" + hostname
+		case webappv1.RouteProtocolTLS:
+			return "tls://" + hostname
+		}
+		return "http://" + hostname + pathMatchesFor(routing)[0].Value
+	}
+	return ""
+}
+
+// deploymentAvailable reports whether the Deployment finished rolling out the
+// desired replicas, together with a human readable progress message.
+func deploymentAvailable(myapp *webappv1.Myapp, deployment *appsv1.Deployment) (bool, string) {
+	desired := replicasFor(myapp)
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false, "Waiting for the Deployment spec update to be observed"
+	}
+	if deployment.Status.UpdatedReplicas < desired {
+		return false, fmt.Sprintf("%d of %d replicas updated", deployment.Status.UpdatedReplicas, desired)
+	}
+	if deployment.Status.AvailableReplicas < desired {
+		return false, fmt.Sprintf("%d of %d replicas available", deployment.Status.AvailableReplicas, desired)
+	}
+	return true, fmt.Sprintf("%d of %d replicas available", deployment.Status.AvailableReplicas, desired)
+}
+
+// deploymentDegraded reports whether the Deployment is failing to make progress.
+func deploymentDegraded(deployment *appsv1.Deployment) (string, string, bool) {
+	for _, condition := range deployment.Status.Conditions {
+		switch {
+		case condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == "ProgressDeadlineExceeded":
+			return webappv1.ReasonProgressDeadlineExceeded, condition.Message, true
+		case condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue:
+			return webappv1.ReasonReplicaFailure, condition.Message, true
+		}
+	}
+	return "", "", false
+}
+
+// routeAcceptance summarizes the Accepted conditions reported by the Gateways
+// the route is attached to.
+func routeAcceptance(route client.Object) (metav1.ConditionStatus, string, string) {
+	kind := routeKindOf(route)
+	status := routeStatusOf(route)
+	if len(status.Parents) == 0 {
+		return metav1.ConditionUnknown, webappv1.ReasonPending, kind + " has not been processed by any Gateway yet"
+	}
+
+	var rejected []string
+	for _, parent := range status.Parents {
+		accepted := meta.FindStatusCondition(parent.Conditions, string(gatewayv1.RouteConditionAccepted))
+		if accepted == nil || accepted.Status != metav1.ConditionTrue {
+			message := "pending"
+			if accepted != nil {
+				message = accepted.Message
+			}
+			rejected = append(rejected, fmt.Sprintf("%s: %s", parent.ParentRef.Name, message))
+		}
+	}
+	if len(rejected) > 0 {
+		return metav1.ConditionFalse, webappv1.ReasonNotAccepted,
+			kind + " not accepted by " + strings.Join(rejected, "; ")
+	}
+	return metav1.ConditionTrue, webappv1.ReasonAccepted, kind + " accepted by all Gateways"
+}
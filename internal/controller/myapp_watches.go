@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// requestSet collects reconcile requests without duplicates, keeping their order.
+type requestSet struct {
+	seen     map[types.NamespacedName]struct{}
+	requests []reconcile.Request
+}
+
+func (s *requestSet) add(key types.NamespacedName) {
+	if s.seen == nil {
+		s.seen = make(map[types.NamespacedName]struct{})
+	}
+	if _, ok := s.seen[key]; ok {
+		return
+	}
+	s.seen[key] = struct{}{}
+	s.requests = append(s.requests, reconcile.Request{NamespacedName: key})
+}
+
+// unknownTrigger labels reconciles not queued by a watch event, such as requeues.
+const unknownTrigger = "Unknown"
+
+// triggerKinds remembers the kind of object whose event last queued the
+// request of each Myapp, so that reconcile metrics can be labelled with it.
+// Events coalesced into a single request are attributed to the last one.
+type triggerKinds struct {
+	mu    sync.Mutex
+	kinds map[types.NamespacedName]string
+}
+
+func (t *triggerKinds) record(key types.NamespacedName, kind string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.kinds == nil {
+		t.kinds = make(map[types.NamespacedName]string)
+	}
+	t.kinds[key] = kind
+}
+
+// take returns and forgets the kind that triggered the request of a Myapp.
+func (t *triggerKinds) take(key types.NamespacedName) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kind, ok := t.kinds[key]
+	if !ok {
+		return unknownTrigger
+	}
+	delete(t.kinds, key)
+	return kind
+}
+
+// predicate records kind as the trigger of the Myapp an event maps to. It never
+// filters events out.
+func (t *triggerKinds) predicate(
+	kind string, myappFor func(client.Object) (types.NamespacedName, bool),
+) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		if key, ok := myappFor(obj); ok {
+			t.record(key, kind)
+		}
+		return true
+	})
+}
+
+// mapFunc wraps a map function so that kind is recorded as the trigger of the
+// requests it returns.
+func (t *triggerKinds) mapFunc(kind string, fn handler.MapFunc) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		requests := fn(ctx, obj)
+		for _, request := range requests {
+			t.record(request.NamespacedName, kind)
+		}
+		return requests
+	}
+}
+
+// objectKey returns the key of an object, for events on Myapps themselves.
+func objectKey(obj client.Object) (types.NamespacedName, bool) {
+	return client.ObjectKeyFromObject(obj), true
+}
+
+// owningMyapp returns the Myapp an object was generated for, based on its
+// controller owner reference or, failing that, on its Myapp label.
+func owningMyapp(obj client.Object) (types.NamespacedName, bool) {
+	if owner := metav1.GetControllerOf(obj); owner != nil && owner.Kind == "Myapp" {
+		if gv, err := schema.ParseGroupVersion(owner.APIVersion); err == nil && gv.Group == webappv1.GroupVersion.Group {
+			return types.NamespacedName{Namespace: obj.GetNamespace(), Name: owner.Name}, true
+		}
+	}
+	if name := obj.GetLabels()[myappLabel]; name != "" {
+		return types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, true
+	}
+	return types.NamespacedName{}, false
+}
+
+// backendServiceKeys returns the Services referenced by the backendRefs of a route.
+func backendServiceKeys(route client.Object) []types.NamespacedName {
+	var keys []types.NamespacedName
+	for _, backendRefs := range routeBackendRefs(route) {
+		for _, backendRef := range backendRefs {
+			if key, ok := backendServiceKey(route.GetNamespace(), backendRef); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// backendServiceKey returns the Service a backendRef points at, if it points at a Service.
+func backendServiceKey(routeNamespace string, ref gatewayv1.BackendObjectReference) (types.NamespacedName, bool) {
+	if ref.Group != nil && *ref.Group != "" {
+		return types.NamespacedName{}, false
+	}
+	if ref.Kind != nil && *ref.Kind != "Service" {
+		return types.NamespacedName{}, false
+	}
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: string(ref.Name)}, true
+}
+
+// myappsForRoute maps an event on a route of any kind to the Myapp that owns
+// the route and to the Myapps whose Services the route sends traffic to.
+func (r *MyappReconciler) myappsForRoute(ctx context.Context, route client.Object) []reconcile.Request {
+	var requests requestSet
+	if key, ok := owningMyapp(route); ok {
+		requests.add(key)
+	}
+
+	for _, serviceKey := range backendServiceKeys(route) {
+		var service corev1.Service
+		if err := r.Get(ctx, serviceKey, &service); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				logf.FromContext(ctx).Info("Failed to get backend Service", "service", serviceKey, "error", err)
+			}
+			continue
+		}
+		if key, ok := owningMyapp(&service); ok {
+			requests.add(key)
+		}
+	}
+
+	return requests.requests
+}
+
+// myappsForGateway maps a Gateway event to the Myapps of the routes, in any
+// namespace, that reference the Gateway as a parent.
+func (r *MyappReconciler) myappsForGateway(ctx context.Context, gateway client.Object) []reconcile.Request {
+	var requests requestSet
+	gatewayObjKey := client.ObjectKeyFromObject(gateway)
+	for _, route := range r.listRoutes(ctx, metav1.NamespaceAll) {
+		for _, parentRef := range routeParentRefs(route) {
+			if key, ok := gatewayKey(route.GetNamespace(), parentRef); !ok || key != gatewayObjKey {
+				continue
+			}
+			for _, request := range r.myappsForRoute(ctx, route) {
+				requests.add(request.NamespacedName)
+			}
+			break
+		}
+	}
+	return requests.requests
+}
+
+// myappsForGatewayClass maps a GatewayClass event to the Myapps of the routes
+// attached to the Gateways of the class.
+func (r *MyappReconciler) myappsForGatewayClass(ctx context.Context, gatewayClass client.Object) []reconcile.Request {
+	var gateways gatewayv1.GatewayList
+	if err := r.List(ctx, &gateways); err != nil {
+		logf.FromContext(ctx).Info("Failed to list Gateways", "error", err)
+		return nil
+	}
+
+	var requests requestSet
+	for i := range gateways.Items {
+		if string(gateways.Items[i].Spec.GatewayClassName) != gatewayClass.GetName() {
+			continue
+		}
+		for _, request := range r.myappsForGateway(ctx, &gateways.Items[i]) {
+			requests.add(request.NamespacedName)
+		}
+	}
+	return requests.requests
+}
+
+// myappsForService maps a Service event to the Myapp that owns the Service and
+// to the Myapps whose routes reference it as a backend, from its namespace or
+// from the namespaces its ReferenceGrants allow.
+func (r *MyappReconciler) myappsForService(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests requestSet
+	if key, ok := owningMyapp(obj); ok {
+		requests.add(key)
+	}
+
+	namespaces := []string{obj.GetNamespace()}
+	grants, err := r.listNamespaceGrants(ctx, obj.GetNamespace())
+	if err != nil {
+		logf.FromContext(ctx).Info("Failed to list ReferenceGrants", "error", err)
+	}
+	serviceKey := client.ObjectKeyFromObject(obj)
+	for i := range grants {
+		for _, from := range grants[i].Spec.From {
+			if backendRefPermitted(string(from.Kind), string(from.Namespace), serviceKey, grants[i:i+1]) &&
+				!slices.Contains(namespaces, string(from.Namespace)) {
+				namespaces = append(namespaces, string(from.Namespace))
+			}
+		}
+	}
+
+	var routes []client.Object
+	for _, namespace := range namespaces {
+		routes = append(routes, r.listRoutes(ctx, namespace)...)
+	}
+	for _, route := range routes {
+		for _, key := range backendServiceKeys(route) {
+			if key != serviceKey {
+				continue
+			}
+			if owner, ok := owningMyapp(route); ok {
+				requests.add(owner)
+			}
+			break
+		}
+	}
+
+	return requests.requests
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// brokenParentRef is a route parentRef the referenced Gateway does not attach
+// the route to.
+type brokenParentRef struct {
+	kind    string
+	route   types.NamespacedName
+	gateway types.NamespacedName
+	section string
+	reason  string
+	message string
+}
+
+// toStatus converts the broken parentRef to its Myapp status representation.
+func (b brokenParentRef) toStatus() webappv1.BrokenParentRef {
+	return webappv1.BrokenParentRef{
+		Kind:        b.kind,
+		Route:       b.route.String(),
+		Gateway:     b.gateway.String(),
+		SectionName: b.section,
+		Reason:      b.reason,
+		Message:     b.message,
+	}
+}
+
+// gatewayObjects holds the Gateways referenced by routes, and the
+// GatewayClasses and namespaces their listeners are checked against.
+type gatewayObjects struct {
+	gateways map[types.NamespacedName]*gatewayv1.Gateway
+	// unreadable holds the Gateways that could not be read, for instance
+	// because they are outside the watched namespaces. Their parentRefs are
+	// not checked.
+	unreadable map[types.NamespacedName]bool
+	classes    map[string]bool
+	// namespaces holds the namespaces of the routes. Selector based
+	// allowedRoutes are not checked for routes of other namespaces.
+	namespaces map[string]*corev1.Namespace
+}
+
+// gatewayKey returns the Gateway a parentRef points at, if it points at a Gateway.
+func gatewayKey(routeNamespace string, ref gatewayv1.ParentReference) (types.NamespacedName, bool) {
+	if ref.Group != nil && *ref.Group != gatewayv1.GroupName {
+		return types.NamespacedName{}, false
+	}
+	if ref.Kind != nil && *ref.Kind != "Gateway" {
+		return types.NamespacedName{}, false
+	}
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: string(ref.Name)}, true
+}
+
+// checkParentRefs checks the Gateway parentRefs of the given routes against
+// the referenced Gateways and returns those the Gateway would not attach the
+// route to: the Gateway or its GatewayClass does not exist, no listener has
+// the referenced section name or port, or the allowedRoutes or hostnames of
+// the listeners do not admit the route. ParentRefs to kinds other than Gateway
+// are not checked.
+func checkParentRefs(routes []client.Object, objs gatewayObjects) []brokenParentRef {
+	var broken []brokenParentRef
+	for _, route := range routes {
+		kind := routeKindOf(route)
+		routeKey := client.ObjectKeyFromObject(route)
+		for _, parentRef := range routeParentRefs(route) {
+			key, ok := gatewayKey(route.GetNamespace(), parentRef)
+			if !ok || objs.unreadable[key] {
+				continue
+			}
+
+			ref := brokenParentRef{kind: kind, route: routeKey, gateway: key}
+			if parentRef.SectionName != nil {
+				ref.section = string(*parentRef.SectionName)
+			}
+			ref.reason, ref.message = parentRefProblem(route, parentRef, key, objs)
+			if ref.reason != "" {
+				broken = append(broken, ref)
+			}
+		}
+	}
+	return broken
+}
+
+// parentRefProblem returns the reason and message of the first problem that
+// keeps the Gateway from attaching the route, or empty strings when it attaches it.
+func parentRefProblem(
+	route client.Object, parentRef gatewayv1.ParentReference, key types.NamespacedName, objs gatewayObjects,
+) (string, string) {
+	kind := routeKindOf(route)
+	gateway, found := objs.gateways[key]
+	if !found {
+		return webappv1.ParentReasonGatewayNotFound, fmt.Sprintf("Gateway %s does not exist", key)
+	}
+	if !objs.classes[string(gateway.Spec.GatewayClassName)] {
+		return webappv1.ParentReasonGatewayClassNotFound,
+			fmt.Sprintf("GatewayClass %s of Gateway %s does not exist", gateway.Spec.GatewayClassName, key)
+	}
+
+	var listeners []gatewayv1.Listener
+	for _, listener := range gateway.Spec.Listeners {
+		if parentRef.SectionName != nil && listener.Name != *parentRef.SectionName {
+			continue
+		}
+		if parentRef.Port != nil && listener.Port != *parentRef.Port {
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		var wanted []string
+		if parentRef.SectionName != nil {
+			wanted = append(wanted, fmt.Sprintf("named %s", *parentRef.SectionName))
+		}
+		if parentRef.Port != nil {
+			wanted = append(wanted, fmt.Sprintf("on port %d", *parentRef.Port))
+		}
+		return webappv1.ParentReasonNoMatchingParent,
+			fmt.Sprintf("Gateway %s has no listener %s", key, strings.Join(wanted, " "))
+	}
+
+	listeners = filterListeners(listeners, func(listener gatewayv1.Listener) bool {
+		return listenerAllowsNamespace(listener, key.Namespace, objs.namespaces[route.GetNamespace()], route.GetNamespace())
+	})
+	if len(listeners) == 0 {
+		return webappv1.ParentReasonNotAllowedByListeners,
+			fmt.Sprintf("listeners of Gateway %s do not allow routes from namespace %s", key, route.GetNamespace())
+	}
+	listeners = filterListeners(listeners, func(listener gatewayv1.Listener) bool {
+		return listenerAllowsKind(listener, kind)
+	})
+	if len(listeners) == 0 {
+		return webappv1.ParentReasonNotAllowedByListeners,
+			fmt.Sprintf("listeners of Gateway %s do not allow %ss", key, kind)
+	}
+	listeners = filterListeners(listeners, func(listener gatewayv1.Listener) bool {
+		return listenerMatchesHostnames(listener, routeHostnames(route))
+	})
+	if len(listeners) == 0 {
+		return webappv1.ParentReasonNoMatchingListenerHostname,
+			fmt.Sprintf("no hostname of the %s matches the listeners of Gateway %s", kind, key)
+	}
+	return "", ""
+}
+
+// filterListeners returns the listeners keep returns true for.
+func filterListeners(listeners []gatewayv1.Listener, keep func(gatewayv1.Listener) bool) []gatewayv1.Listener {
+	var kept []gatewayv1.Listener
+	for _, listener := range listeners {
+		if keep(listener) {
+			kept = append(kept, listener)
+		}
+	}
+	return kept
+}
+
+// listenerAllowsNamespace reports whether the allowedRoutes of a listener admit
+// routes of the given namespace. Selectors are not checked, and admit the
+// route, when the namespace is unknown.
+func listenerAllowsNamespace(
+	listener gatewayv1.Listener, gatewayNamespace string, namespace *corev1.Namespace, routeNamespace string,
+) bool {
+	from := gatewayv1.NamespacesFromSame
+	var selector *metav1.LabelSelector
+	if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil {
+		if listener.AllowedRoutes.Namespaces.From != nil {
+			from = *listener.AllowedRoutes.Namespaces.From
+		}
+		selector = listener.AllowedRoutes.Namespaces.Selector
+	}
+
+	switch from {
+	case gatewayv1.NamespacesFromAll:
+		return true
+	case gatewayv1.NamespacesFromSelector:
+		if namespace == nil {
+			return true
+		}
+		s, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return false
+		}
+		return s.Matches(labels.Set(namespace.Labels))
+	default:
+		return gatewayNamespace == routeNamespace
+	}
+}
+
+// listenerAllowsKind reports whether a listener admits routes of the given
+// kind: the kinds listed in its allowedRoutes or, without them, the kinds the
+// Gateway API specifies for its protocol.
+func listenerAllowsKind(listener gatewayv1.Listener, kind string) bool {
+	if listener.AllowedRoutes != nil && len(listener.AllowedRoutes.Kinds) > 0 {
+		for _, allowed := range listener.AllowedRoutes.Kinds {
+			if (allowed.Group == nil || *allowed.Group == gatewayv1.GroupName) && string(allowed.Kind) == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch listener.Protocol {
+	case gatewayv1.HTTPProtocolType, gatewayv1.HTTPSProtocolType:
+		return kind == "HTTPRoute" || kind == "GRPCRoute"
+	case gatewayv1.TLSProtocolType:
+		return kind == "TLSRoute" || kind == "TCPRoute"
+	case gatewayv1.TCPProtocolType:
+		return kind == "TCPRoute"
+	default:
+		// Implementation specific protocols
+		return true
+	}
+}
+
+// listenerMatchesHostnames reports whether a route with the given hostnames
+// can attach to a listener: either has no hostname, or one of the route
+// hostnames intersects the listener hostname.
+func listenerMatchesHostnames(listener gatewayv1.Listener, hostnames []gatewayv1.Hostname) bool {
+	if listener.Hostname == nil || *listener.Hostname == "" || len(hostnames) == 0 {
+		return true
+	}
+	for _, hostname := range hostnames {
+		if hostnamesIntersect(string(*listener.Hostname), string(hostname)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostnamesIntersect reports whether two hostnames, either of which may be a
+// wildcard such as *.example.com, match a common hostname. A wildcard matches
+// one or more labels, but not the domain it is prefixed to.
+func hostnamesIntersect(a, b string) bool {
+	switch {
+	case a == b:
+		return true
+	case strings.HasPrefix(a, "*.") && strings.HasSuffix(b, a[1:]):
+		return true
+	case strings.HasPrefix(b, "*.") && strings.HasSuffix(a, b[1:]):
+		return true
+	default:
+		return false
+	}
+}
+
+// routesFor returns the routes relevant to a Myapp: those it owns and those
+// sending traffic to a Service generated for it.
+func routesFor(myapp *webappv1.Myapp, routes []client.Object) []client.Object {
+	myappKey := types.NamespacedName{Namespace: myapp.Namespace, Name: myapp.Name}
+	services := generatedServiceKeys(myapp)
+
+	var relevant []client.Object
+	for _, route := range routes {
+		if owner, ok := owningMyapp(route); ok && owner == myappKey {
+			relevant = append(relevant, route)
+			continue
+		}
+		for _, serviceKey := range backendServiceKeys(route) {
+			if services[serviceKey] {
+				relevant = append(relevant, route)
+				break
+			}
+		}
+	}
+	return relevant
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// backendRefPermitted reports whether a route of the given kind and namespace
+// may reference the Service: always within its own namespace, and otherwise
+// when a ReferenceGrant in the namespace of the Service allows it.
+func backendRefPermitted(
+	kind, routeNamespace string, service types.NamespacedName, grants []gatewayv1beta1.ReferenceGrant,
+) bool {
+	if service.Namespace == routeNamespace {
+		return true
+	}
+	for _, grant := range grants {
+		if grant.Namespace != service.Namespace || !grantAllowsFrom(&grant, kind, routeNamespace) {
+			continue
+		}
+		for _, to := range grant.Spec.To {
+			if to.Group == "" && to.Kind == "Service" && (to.Name == nil || string(*to.Name) == service.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// grantAllowsFrom reports whether a ReferenceGrant allows references from
+// routes of the given kind and namespace.
+func grantAllowsFrom(grant *gatewayv1beta1.ReferenceGrant, kind, namespace string) bool {
+	for _, from := range grant.Spec.From {
+		if from.Group == gatewayv1.GroupName && string(from.Kind) == kind && string(from.Namespace) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// crossNamespaceBackends returns the namespaces, other than their own, that
+// backendRefs of the routes point at.
+func crossNamespaceBackends(routes []client.Object) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, route := range routes {
+		for _, key := range backendServiceKeys(route) {
+			if key.Namespace != route.GetNamespace() && !seen[key.Namespace] {
+				seen[key.Namespace] = true
+				namespaces = append(namespaces, key.Namespace)
+			}
+		}
+	}
+	return namespaces
+}
+
+// listReferenceGrants lists the ReferenceGrants of the namespaces backendRefs
+// of the routes point at from another namespace.
+func (r *MyappReconciler) listReferenceGrants(
+	ctx context.Context, routes []client.Object,
+) []gatewayv1beta1.ReferenceGrant {
+	var grants []gatewayv1beta1.ReferenceGrant
+	for _, namespace := range crossNamespaceBackends(routes) {
+		items, err := r.listNamespaceGrants(ctx, namespace)
+		if err != nil {
+			logf.FromContext(ctx).Info("Failed to list ReferenceGrants", "namespace", namespace, "error", err)
+			continue
+		}
+		grants = append(grants, items...)
+	}
+	return grants
+}
+
+// listNamespaceGrants lists the ReferenceGrants of a namespace from the cache,
+// or from the API server when the cache does not cover the namespace, e.g.
+// outside the watched namespaces.
+func (r *MyappReconciler) listNamespaceGrants(
+	ctx context.Context, namespace string,
+) ([]gatewayv1beta1.ReferenceGrant, error) {
+	var list gatewayv1beta1.ReferenceGrantList
+	err := r.List(ctx, &list, client.InNamespace(namespace))
+	if err != nil && !meta.IsNoMatchError(err) && r.APIReader != nil {
+		err = r.APIReader.List(ctx, &list, client.InNamespace(namespace))
+	}
+	return list.Items, err
+}
+
+// readCrossNamespaceServices adds to services the backends of the routes that
+// live in other namespaces than the routes, which the namespace listing does
+// not hold. They are read from the cache, or from the API server when the
+// cache does not cover their namespace.
+func (r *MyappReconciler) readCrossNamespaceServices(
+	ctx context.Context, routes []client.Object, services []corev1.Service,
+) []corev1.Service {
+	known := make(map[types.NamespacedName]bool, len(services))
+	for i := range services {
+		known[client.ObjectKeyFromObject(&services[i])] = true
+	}
+	for _, route := range routes {
+		for _, key := range backendServiceKeys(route) {
+			if key.Namespace == route.GetNamespace() || known[key] {
+				continue
+			}
+			known[key] = true
+
+			var service corev1.Service
+			err := r.Get(ctx, key, &service)
+			if err != nil && !apierrors.IsNotFound(err) && r.APIReader != nil {
+				err = r.APIReader.Get(ctx, key, &service)
+			}
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					logf.FromContext(ctx).Info("Failed to read backend Service", "service", key, "error", err)
+				}
+				continue
+			}
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// myappsForReferenceGrant maps a ReferenceGrant event to the Myapps of the
+// routes, in the namespaces it grants access from, that reference Services in
+// its namespace.
+func (r *MyappReconciler) myappsForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	grant, ok := obj.(*gatewayv1beta1.ReferenceGrant)
+	if !ok {
+		return nil
+	}
+
+	var requests requestSet
+	seen := make(map[gatewayv1.Namespace]bool)
+	for _, from := range grant.Spec.From {
+		if from.Group != gatewayv1.GroupName || seen[from.Namespace] {
+			continue
+		}
+		seen[from.Namespace] = true
+		for _, route := range r.listRoutes(ctx, string(from.Namespace)) {
+			for _, key := range backendServiceKeys(route) {
+				if key.Namespace != grant.Namespace {
+					continue
+				}
+				for _, request := range r.myappsForRoute(ctx, route) {
+					requests.add(request.NamespacedName)
+				}
+				break
+			}
+		}
+	}
+	return requests.requests
+}
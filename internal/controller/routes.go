@@ -0,0 +1,296 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// missingRouteKindRequeueInterval is how often a Myapp routed through a kind
+// whose CRD is not installed is reconciled again.
+const missingRouteKindRequeueInterval = time.Minute
+
+// optionalRouteKind is a route kind, besides HTTPRoute, that the controller
+// generates and checks when its CRD is installed.
+type optionalRouteKind struct {
+	// object is an empty route of the kind.
+	object client.Object
+	// newList returns an empty list of routes of the kind.
+	newList func() client.ObjectList
+	// filter restricts the routes of the kind that are cached and trigger reconciles.
+	filter WatchFilter
+}
+
+// optionalRouteKinds returns the route kinds the controller only watches when
+// their CRDs are installed.
+func (r *MyappReconciler) optionalRouteKinds() []optionalRouteKind {
+	return []optionalRouteKind{
+		{
+			object:  &gatewayv1.GRPCRoute{},
+			newList: func() client.ObjectList { return &gatewayv1.GRPCRouteList{} },
+			filter:  r.GRPCRouteFilter,
+		},
+		{
+			object:  &gatewayv1alpha2.TCPRoute{},
+			newList: func() client.ObjectList { return &gatewayv1alpha2.TCPRouteList{} },
+			filter:  r.TCPRouteFilter,
+		},
+		{
+			object:  &gatewayv1alpha2.TLSRoute{},
+			newList: func() client.ObjectList { return &gatewayv1alpha2.TLSRouteList{} },
+			filter:  r.TLSRouteFilter,
+		},
+	}
+}
+
+// protocolFor returns the protocol of a Myapp routing spec, applying the default.
+func protocolFor(routing *webappv1.RoutingSpec) webappv1.RouteProtocol {
+	if routing.Protocol == "" {
+		return webappv1.RouteProtocolHTTP
+	}
+	return routing.Protocol
+}
+
+// newRoute returns an empty route of the kind generated for a routing protocol.
+func newRoute(protocol webappv1.RouteProtocol) client.Object {
+	switch protocol {
+	case webappv1.RouteProtocolGRPC:
+		return &gatewayv1.GRPCRoute{}
+	case webappv1.RouteProtocolTCP:
+		return &gatewayv1alpha2.TCPRoute{}
+	case webappv1.RouteProtocolTLS:
+		return &gatewayv1alpha2.TLSRoute{}
+	default:
+		return &gatewayv1.HTTPRoute{}
+	}
+}
+
+// routeProtocols lists the protocols a Myapp can be routed with, each
+// generating a different route kind.
+var routeProtocols = []webappv1.RouteProtocol{
+	webappv1.RouteProtocolHTTP,
+	webappv1.RouteProtocolGRPC,
+	webappv1.RouteProtocolTCP,
+	webappv1.RouteProtocolTLS,
+}
+
+// routeKindOf returns the kind of a route, which objects read from the API
+// server do not carry in their type meta.
+func routeKindOf(route client.Object) string {
+	switch route.(type) {
+	case *gatewayv1.GRPCRoute:
+		return "GRPCRoute"
+	case *gatewayv1alpha2.TCPRoute:
+		return "TCPRoute"
+	case *gatewayv1alpha2.TLSRoute:
+		return "TLSRoute"
+	default:
+		return "HTTPRoute"
+	}
+}
+
+// routeStatusOf returns the status shared by every route kind.
+func routeStatusOf(route client.Object) *gatewayv1.RouteStatus {
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		return &route.Status.RouteStatus
+	case *gatewayv1.GRPCRoute:
+		return &route.Status.RouteStatus
+	case *gatewayv1alpha2.TCPRoute:
+		return &route.Status.RouteStatus
+	case *gatewayv1alpha2.TLSRoute:
+		return &route.Status.RouteStatus
+	default:
+		return &gatewayv1.RouteStatus{}
+	}
+}
+
+// routeParentRefs returns the parentRefs shared by every route kind.
+func routeParentRefs(route client.Object) []gatewayv1.ParentReference {
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		return route.Spec.ParentRefs
+	case *gatewayv1.GRPCRoute:
+		return route.Spec.ParentRefs
+	case *gatewayv1alpha2.TCPRoute:
+		return route.Spec.ParentRefs
+	case *gatewayv1alpha2.TLSRoute:
+		return route.Spec.ParentRefs
+	default:
+		return nil
+	}
+}
+
+// routeHostnames returns the hostnames of a route, none for TCPRoutes.
+func routeHostnames(route client.Object) []gatewayv1.Hostname {
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		return route.Spec.Hostnames
+	case *gatewayv1.GRPCRoute:
+		return route.Spec.Hostnames
+	case *gatewayv1alpha2.TLSRoute:
+		return route.Spec.Hostnames
+	default:
+		return nil
+	}
+}
+
+// routeBackendRefs returns the backendRefs of each rule of a route.
+func routeBackendRefs(route client.Object) [][]gatewayv1.BackendObjectReference {
+	var rules [][]gatewayv1.BackendObjectReference
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		for _, rule := range route.Spec.Rules {
+			refs := make([]gatewayv1.BackendObjectReference, 0, len(rule.BackendRefs))
+			for _, backendRef := range rule.BackendRefs {
+				refs = append(refs, backendRef.BackendObjectReference)
+			}
+			rules = append(rules, refs)
+		}
+	case *gatewayv1.GRPCRoute:
+		for _, rule := range route.Spec.Rules {
+			refs := make([]gatewayv1.BackendObjectReference, 0, len(rule.BackendRefs))
+			for _, backendRef := range rule.BackendRefs {
+				refs = append(refs, backendRef.BackendObjectReference)
+			}
+			rules = append(rules, refs)
+		}
+	case *gatewayv1alpha2.TCPRoute:
+		for _, rule := range route.Spec.Rules {
+			rules = append(rules, backendObjectRefs(rule.BackendRefs))
+		}
+	case *gatewayv1alpha2.TLSRoute:
+		for _, rule := range route.Spec.Rules {
+			rules = append(rules, backendObjectRefs(rule.BackendRefs))
+		}
+	}
+	return rules
+}
+
+// backendObjectRefs returns the object references of plain backendRefs, as
+// used by the rules of the experimental route kinds.
+func backendObjectRefs(backendRefs []gatewayv1.BackendRef) []gatewayv1.BackendObjectReference {
+	refs := make([]gatewayv1.BackendObjectReference, 0, len(backendRefs))
+	for _, backendRef := range backendRefs {
+		refs = append(refs, backendRef.BackendObjectReference)
+	}
+	return refs
+}
+
+// mutateRoute sets the fields of the route managed by the Myapp.
+func mutateRoute(myapp *webappv1.Myapp, route client.Object) {
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		mutateHTTPRoute(myapp, route)
+	case *gatewayv1.GRPCRoute:
+		mutateGRPCRoute(myapp, route)
+	case *gatewayv1alpha2.TCPRoute:
+		mutateTCPRoute(myapp, route)
+	case *gatewayv1alpha2.TLSRoute:
+		mutateTLSRoute(myapp, route)
+	}
+}
+
+// routeObjects returns pointers to the routes of a list as client.Objects.
+func routeObjects[T any, PT interface {
+	*T
+	client.Object
+}](routes []T) []client.Object {
+	objs := make([]client.Object, 0, len(routes))
+	for i := range routes {
+		objs = append(objs, PT(&routes[i]))
+	}
+	return objs
+}
+
+// listRoutes lists the routes of every kind in a namespace, or in every
+// namespace for metav1.NamespaceAll.
+func (r *MyappReconciler) listRoutes(ctx context.Context, namespace string) []client.Object {
+	var routes []client.Object
+	var httpRoutes gatewayv1.HTTPRouteList
+	if err := r.List(ctx, &httpRoutes, client.InNamespace(namespace)); err != nil {
+		logf.FromContext(ctx).Info("Failed to list HTTPRoutes (Gateway API may not be available)", "error", err)
+	} else {
+		routes = routeObjects(httpRoutes.Items)
+	}
+	return append(routes, r.listOptionalRoutes(ctx, namespace)...)
+}
+
+// listOptionalRoutes lists the routes of the optional kinds in a namespace,
+// skipping the kinds whose CRDs are not installed.
+func (r *MyappReconciler) listOptionalRoutes(ctx context.Context, namespace string) []client.Object {
+	var routes []client.Object
+	for _, kind := range r.optionalRouteKinds() {
+		list := kind.newList()
+		if err := r.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			if !meta.IsNoMatchError(err) {
+				logf.FromContext(ctx).Info("Failed to list routes", "kind", routeKindOf(kind.object), "error", err)
+			}
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			if route, ok := item.(client.Object); ok {
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes
+}
+
+// upsertRoute replaces the route of the same kind, namespace and name in
+// routes, or appends it.
+func upsertRoute(routes []client.Object, route client.Object) []client.Object {
+	for i := range routes {
+		if routeKindOf(routes[i]) == routeKindOf(route) &&
+			client.ObjectKeyFromObject(routes[i]) == client.ObjectKeyFromObject(route) {
+			routes[i] = route
+			return routes
+		}
+	}
+	return append(routes, route)
+}
+
+// kindInstalled reports whether the API server serves the kind of an object.
+func kindInstalled(mgr ctrl.Manager, obj client.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, mgr.GetScheme())
+	if err != nil {
+		return false, err
+	}
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up %s: %w", gvk.Kind, err)
+	}
+	return true, nil
+}
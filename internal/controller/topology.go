@@ -0,0 +1,537 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// Topology is the routing graph of a set of namespaces: the Gateways, the
+// routes attaching to them and their rules, the backend Services of the rules
+// with their endpoints and workloads, and the Myapps the objects were generated
+// for.
+type Topology struct {
+	Namespaces []NamespaceTopology `json:"namespaces"`
+}
+
+// NamespaceTopology holds the nodes of a namespace. Edges point at nodes of
+// any namespace by their namespace/name.
+type NamespaceTopology struct {
+	Name     string        `json:"name"`
+	Gateways []GatewayNode `json:"gateways,omitempty"`
+	Routes   []RouteNode   `json:"routes,omitempty"`
+	Services []ServiceNode `json:"services,omitempty"`
+	Myapps   []MyappNode   `json:"myapps,omitempty"`
+}
+
+// GatewayNode is a Gateway and its listeners.
+type GatewayNode struct {
+	Name             string         `json:"name"`
+	GatewayClassName string         `json:"gatewayClassName"`
+	Listeners        []ListenerNode `json:"listeners,omitempty"`
+}
+
+// ListenerNode is a listener of a Gateway.
+type ListenerNode struct {
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// RouteNode is a route of any kind, with an edge to each of its parent
+// Gateways and its rules.
+type RouteNode struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Myapp     string       `json:"myapp,omitempty"`
+	Hostnames []string     `json:"hostnames,omitempty"`
+	Parents   []ParentEdge `json:"parents,omitempty"`
+	Rules     []RuleNode   `json:"rules,omitempty"`
+}
+
+// ParentEdge links a route to a Gateway it references as a parent.
+type ParentEdge struct {
+	Gateway     string      `json:"gateway"`
+	SectionName string      `json:"sectionName,omitempty"`
+	Broken      *BrokenEdge `json:"broken,omitempty"`
+}
+
+// RuleNode is a rule of a route: the requests it matches and the backends it
+// sends them to. Layer 4 rules match every connection.
+type RuleNode struct {
+	Matches  []string      `json:"matches,omitempty"`
+	Backends []BackendEdge `json:"backends,omitempty"`
+}
+
+// BackendEdge links a rule to a backend, a Service unless Kind says otherwise.
+type BackendEdge struct {
+	Kind    string      `json:"kind,omitempty"`
+	Name    string      `json:"name"`
+	Port    int32       `json:"port,omitempty"`
+	Weight  int32       `json:"weight"`
+	Broken  *BrokenEdge `json:"broken,omitempty"`
+	service types.NamespacedName
+}
+
+// BrokenEdge explains why an edge does not carry traffic, with the reasons
+// reported in the Myapp status.
+type BrokenEdge struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// ServiceNode is a Service, the endpoints of its EndpointSlices and the
+// Deployments its selector matches the Pods of.
+type ServiceNode struct {
+	Name      string            `json:"name"`
+	Myapp     string            `json:"myapp,omitempty"`
+	Ports     []int32           `json:"ports,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Endpoints []EndpointNode    `json:"endpoints,omitempty"`
+	Workloads []string          `json:"workloads,omitempty"`
+}
+
+// EndpointNode is an endpoint of a Service, usually a Pod.
+type EndpointNode struct {
+	Address string `json:"address"`
+	Pod     string `json:"pod,omitempty"`
+	Ready   bool   `json:"ready"`
+}
+
+// MyappNode is a Myapp and the status of its Ready condition.
+type MyappNode struct {
+	Name  string `json:"name"`
+	Ready string `json:"ready"`
+	URL   string `json:"url,omitempty"`
+}
+
+// TopologyObjects holds the objects a Topology is built from.
+type TopologyObjects struct {
+	Gateways        []gatewayv1.Gateway
+	GatewayClasses  []gatewayv1.GatewayClass
+	Routes          []client.Object
+	Services        []corev1.Service
+	EndpointSlices  []discoveryv1.EndpointSlice
+	Deployments     []appsv1.Deployment
+	ReferenceGrants []gatewayv1beta1.ReferenceGrant
+	Namespaces      []corev1.Namespace
+	Myapps          []webappv1.Myapp
+}
+
+// knownNamespaces returns the namespaces holding at least one of the objects.
+// References to objects of other namespaces cannot be checked, as the objects
+// may exist without having been given.
+func (o TopologyObjects) knownNamespaces() map[string]bool {
+	known := make(map[string]bool)
+	for i := range o.Gateways {
+		known[o.Gateways[i].Namespace] = true
+	}
+	for _, route := range o.Routes {
+		known[route.GetNamespace()] = true
+	}
+	for i := range o.Services {
+		known[o.Services[i].Namespace] = true
+	}
+	for i := range o.Deployments {
+		known[o.Deployments[i].Namespace] = true
+	}
+	for i := range o.Myapps {
+		known[o.Myapps[i].Namespace] = true
+	}
+	return known
+}
+
+// gatewayObjects returns the Gateways, GatewayClasses and namespaces parentRefs
+// are checked against. GatewayClasses are only checked when some are given.
+func (o TopologyObjects) gatewayObjects(known map[string]bool) gatewayObjects {
+	objs := gatewayObjects{
+		gateways:   make(map[types.NamespacedName]*gatewayv1.Gateway, len(o.Gateways)),
+		unreadable: make(map[types.NamespacedName]bool),
+		classes:    make(map[string]bool, len(o.GatewayClasses)),
+		namespaces: make(map[string]*corev1.Namespace, len(o.Namespaces)),
+	}
+	for i := range o.Gateways {
+		objs.gateways[client.ObjectKeyFromObject(&o.Gateways[i])] = &o.Gateways[i]
+		if len(o.GatewayClasses) == 0 {
+			objs.classes[string(o.Gateways[i].Spec.GatewayClassName)] = true
+		}
+	}
+	for i := range o.GatewayClasses {
+		objs.classes[o.GatewayClasses[i].Name] = true
+	}
+	for i := range o.Namespaces {
+		objs.namespaces[o.Namespaces[i].Name] = &o.Namespaces[i]
+	}
+	for _, route := range o.Routes {
+		for _, parentRef := range routeParentRefs(route) {
+			if key, ok := gatewayKey(route.GetNamespace(), parentRef); ok && !known[key.Namespace] {
+				objs.unreadable[key] = true
+			}
+		}
+	}
+	return objs
+}
+
+// BuildTopology builds the Topology of the given objects. Broken edges are
+// found with the checks the controller runs on the routes of Myapps: every
+// route is checked, whether or not it sends traffic to a Myapp.
+func BuildTopology(objs TopologyObjects) Topology {
+	known := objs.knownNamespaces()
+
+	type backendKey struct {
+		kind    string
+		route   types.NamespacedName
+		rule    int
+		service types.NamespacedName
+		port    int32
+	}
+	brokenBackends := make(map[backendKey]*BrokenEdge)
+	for _, ref := range checkBackendRefs(objs.Routes, objs.Services, objs.ReferenceGrants) {
+		if ref.reason == webappv1.BackendReasonServiceNotFound && !known[ref.service.Namespace] {
+			continue
+		}
+		key := backendKey{ref.kind, ref.route, ref.rule, ref.service, ref.port}
+		brokenBackends[key] = &BrokenEdge{Reason: ref.reason, Message: ref.message}
+	}
+
+	type parentKey struct {
+		kind    string
+		route   types.NamespacedName
+		gateway types.NamespacedName
+		section string
+	}
+	brokenParents := make(map[parentKey]*BrokenEdge)
+	for _, ref := range checkParentRefs(objs.Routes, objs.gatewayObjects(known)) {
+		brokenParents[parentKey{ref.kind, ref.route, ref.gateway, ref.section}] = &BrokenEdge{
+			Reason: ref.reason, Message: ref.message,
+		}
+	}
+
+	namespaces := make(map[string]*NamespaceTopology)
+	namespace := func(name string) *NamespaceTopology {
+		if namespaces[name] == nil {
+			namespaces[name] = &NamespaceTopology{Name: name}
+		}
+		return namespaces[name]
+	}
+
+	for i := range objs.Gateways {
+		gateway := &objs.Gateways[i]
+		node := GatewayNode{Name: gateway.Name, GatewayClassName: string(gateway.Spec.GatewayClassName)}
+		for _, listener := range gateway.Spec.Listeners {
+			node.Listeners = append(node.Listeners, ListenerNode{
+				Name:     string(listener.Name),
+				Protocol: string(listener.Protocol),
+				Port:     int32(listener.Port),
+				Hostname: string(ptr.Deref(listener.Hostname, "")),
+			})
+		}
+		ns := namespace(gateway.Namespace)
+		ns.Gateways = append(ns.Gateways, node)
+	}
+
+	for _, route := range objs.Routes {
+		kind := routeKindOf(route)
+		routeKey := client.ObjectKeyFromObject(route)
+		node := RouteNode{Kind: kind, Name: route.GetName(), Myapp: owningMyappName(route)}
+		for _, hostname := range routeHostnames(route) {
+			node.Hostnames = append(node.Hostnames, string(hostname))
+		}
+		for _, parentRef := range routeParentRefs(route) {
+			key, ok := gatewayKey(route.GetNamespace(), parentRef)
+			if !ok {
+				continue
+			}
+			edge := ParentEdge{Gateway: key.String(), SectionName: string(ptr.Deref(parentRef.SectionName, ""))}
+			edge.Broken = brokenParents[parentKey{kind, routeKey, key, edge.SectionName}]
+			node.Parents = append(node.Parents, edge)
+		}
+		node.Rules = routeRuleNodes(route)
+		for i := range node.Rules {
+			for j := range node.Rules[i].Backends {
+				backend := &node.Rules[i].Backends[j]
+				if backend.Kind == "" {
+					backend.Broken = brokenBackends[backendKey{kind, routeKey, i, backend.service, backend.Port}]
+				}
+			}
+		}
+		ns := namespace(route.GetNamespace())
+		ns.Routes = append(ns.Routes, node)
+	}
+
+	endpoints := make(map[types.NamespacedName][]EndpointNode)
+	for i := range objs.EndpointSlices {
+		slice := &objs.EndpointSlices[i]
+		service := types.NamespacedName{Namespace: slice.Namespace, Name: slice.Labels[discoveryv1.LabelServiceName]}
+		for _, endpoint := range slice.Endpoints {
+			node := EndpointNode{Ready: ptr.Deref(endpoint.Conditions.Ready, true)}
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				node.Pod = endpoint.TargetRef.Name
+			}
+			for _, address := range endpoint.Addresses {
+				node.Address = address
+				endpoints[service] = append(endpoints[service], node)
+			}
+		}
+	}
+
+	for i := range objs.Services {
+		service := &objs.Services[i]
+		node := ServiceNode{
+			Name:      service.Name,
+			Myapp:     owningMyappName(service),
+			Selector:  service.Spec.Selector,
+			Endpoints: endpoints[client.ObjectKeyFromObject(service)],
+		}
+		for _, port := range service.Spec.Ports {
+			node.Ports = append(node.Ports, port.Port)
+		}
+		if len(service.Spec.Selector) > 0 {
+			selector := labels.SelectorFromSet(service.Spec.Selector)
+			for j := range objs.Deployments {
+				deployment := &objs.Deployments[j]
+				if deployment.Namespace == service.Namespace &&
+					selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+					node.Workloads = append(node.Workloads, "Deployment/"+deployment.Name)
+				}
+			}
+			slices.Sort(node.Workloads)
+		}
+		ns := namespace(service.Namespace)
+		ns.Services = append(ns.Services, node)
+	}
+
+	for i := range objs.Myapps {
+		myapp := &objs.Myapps[i]
+		node := MyappNode{Name: myapp.Name, Ready: string(metav1.ConditionUnknown), URL: myapp.Status.URL}
+		if ready := meta.FindStatusCondition(myapp.Status.Conditions, webappv1.ConditionReady); ready != nil {
+			node.Ready = string(ready.Status)
+		}
+		ns := namespace(myapp.Namespace)
+		ns.Myapps = append(ns.Myapps, node)
+	}
+
+	topology := Topology{Namespaces: make([]NamespaceTopology, 0, len(namespaces))}
+	for _, ns := range namespaces {
+		slices.SortFunc(ns.Gateways, func(a, b GatewayNode) int { return strings.Compare(a.Name, b.Name) })
+		slices.SortFunc(ns.Routes, func(a, b RouteNode) int {
+			return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.Kind, b.Kind))
+		})
+		slices.SortFunc(ns.Services, func(a, b ServiceNode) int { return strings.Compare(a.Name, b.Name) })
+		slices.SortFunc(ns.Myapps, func(a, b MyappNode) int { return strings.Compare(a.Name, b.Name) })
+		topology.Namespaces = append(topology.Namespaces, *ns)
+	}
+	slices.SortFunc(topology.Namespaces, func(a, b NamespaceTopology) int { return strings.Compare(a.Name, b.Name) })
+	return topology
+}
+
+// owningMyappName returns the name of the Myapp an object was generated for,
+// if any.
+func owningMyappName(obj client.Object) string {
+	if key, ok := owningMyapp(obj); ok {
+		return key.Name
+	}
+	return ""
+}
+
+// routeRuleNodes returns the rules of a route with the requests they match and
+// their backends.
+func routeRuleNodes(route client.Object) []RuleNode {
+	var rules []RuleNode
+	switch route := route.(type) {
+	case *gatewayv1.HTTPRoute:
+		for _, rule := range route.Spec.Rules {
+			node := RuleNode{Matches: []string{"PathPrefix /"}}
+			if len(rule.Matches) > 0 {
+				node.Matches = nil
+			}
+			for _, match := range rule.Matches {
+				node.Matches = append(node.Matches, httpMatchString(match))
+			}
+			for _, backendRef := range rule.BackendRefs {
+				node.Backends = append(node.Backends, backendEdge(route.Namespace, backendRef.BackendRef))
+			}
+			rules = append(rules, node)
+		}
+	case *gatewayv1.GRPCRoute:
+		for _, rule := range route.Spec.Rules {
+			node := RuleNode{Matches: []string{"*"}}
+			if len(rule.Matches) > 0 {
+				node.Matches = nil
+			}
+			for _, match := range rule.Matches {
+				node.Matches = append(node.Matches, grpcMatchString(match))
+			}
+			for _, backendRef := range rule.BackendRefs {
+				node.Backends = append(node.Backends, backendEdge(route.Namespace, backendRef.BackendRef))
+			}
+			rules = append(rules, node)
+		}
+	case *gatewayv1alpha2.TCPRoute:
+		for _, rule := range route.Spec.Rules {
+			rules = append(rules, layer4RuleNode(route.Namespace, rule.BackendRefs))
+		}
+	case *gatewayv1alpha2.TLSRoute:
+		for _, rule := range route.Spec.Rules {
+			rules = append(rules, layer4RuleNode(route.Namespace, rule.BackendRefs))
+		}
+	}
+	return rules
+}
+
+// layer4RuleNode returns the rule of a TCPRoute or TLSRoute.
+func layer4RuleNode(namespace string, backendRefs []gatewayv1.BackendRef) RuleNode {
+	var node RuleNode
+	for _, backendRef := range backendRefs {
+		node.Backends = append(node.Backends, backendEdge(namespace, backendRef))
+	}
+	return node
+}
+
+// httpMatchString describes an HTTPRoute match, e.g. "GET PathPrefix /api".
+func httpMatchString(match gatewayv1.HTTPRouteMatch) string {
+	path := fmt.Sprintf("%s %s", gatewayv1.PathMatchPathPrefix, "/")
+	if match.Path != nil {
+		path = fmt.Sprintf("%s %s",
+			ptr.Deref(match.Path.Type, gatewayv1.PathMatchPathPrefix), ptr.Deref(match.Path.Value, "/"))
+	}
+	if match.Method != nil {
+		return fmt.Sprintf("%s %s", *match.Method, path)
+	}
+	return path
+}
+
+// grpcMatchString describes a GRPCRoute match as service/method, with * for
+// any service or method.
+func grpcMatchString(match gatewayv1.GRPCRouteMatch) string {
+	if match.Method == nil {
+		return "*"
+	}
+	return fmt.Sprintf("%s/%s", ptr.Deref(match.Method.Service, "*"), ptr.Deref(match.Method.Method, "*"))
+}
+
+// backendEdge returns the edge of a backendRef of a route in namespace.
+func backendEdge(namespace string, backendRef gatewayv1.BackendRef) BackendEdge {
+	ref := backendRef.BackendObjectReference
+	edge := BackendEdge{
+		Port:   int32(ptr.Deref(ref.Port, 0)),
+		Weight: ptr.Deref(backendRef.Weight, 1),
+	}
+	key, isService := backendServiceKey(namespace, ref)
+	if !isService {
+		key.Namespace = string(ptr.Deref(ref.Namespace, gatewayv1.Namespace(namespace)))
+		key.Name = string(ref.Name)
+		edge.Kind = string(ptr.Deref(ref.Kind, "Service"))
+		if group := ptr.Deref(ref.Group, ""); group != "" {
+			edge.Kind = fmt.Sprintf("%s.%s", edge.Kind, group)
+		}
+	}
+	edge.Name = key.String()
+	edge.service = key
+	return edge
+}
+
+// ListTopologyObjects lists the objects of a namespace, or of every namespace
+// for metav1.NamespaceAll, that make up its Topology. Gateways and
+// ReferenceGrants are listed in every namespace, as routes reference them
+// across namespaces. Kinds whose CRDs are not installed are skipped.
+func ListTopologyObjects(ctx context.Context, reader client.Reader, namespace string) (TopologyObjects, error) {
+	var objs TopologyObjects
+	inNamespace := client.InNamespace(namespace)
+
+	var myapps webappv1.MyappList
+	if err := reader.List(ctx, &myapps, inNamespace); err != nil {
+		return objs, fmt.Errorf("failed to list Myapps: %w", err)
+	}
+	objs.Myapps = myapps.Items
+
+	var services corev1.ServiceList
+	if err := reader.List(ctx, &services, inNamespace); err != nil {
+		return objs, fmt.Errorf("failed to list Services: %w", err)
+	}
+	objs.Services = services.Items
+
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := reader.List(ctx, &endpointSlices, inNamespace); err != nil {
+		return objs, fmt.Errorf("failed to list EndpointSlices: %w", err)
+	}
+	objs.EndpointSlices = endpointSlices.Items
+
+	var deployments appsv1.DeploymentList
+	if err := reader.List(ctx, &deployments, inNamespace); err != nil {
+		return objs, fmt.Errorf("failed to list Deployments: %w", err)
+	}
+	objs.Deployments = deployments.Items
+
+	// Namespaces are only needed to check the namespace selectors of listeners
+	var namespaces corev1.NamespaceList
+	if err := reader.List(ctx, &namespaces); err == nil {
+		objs.Namespaces = namespaces.Items
+	}
+
+	var gateways gatewayv1.GatewayList
+	var gatewayClasses gatewayv1.GatewayClassList
+	var grants gatewayv1beta1.ReferenceGrantList
+	var httpRoutes gatewayv1.HTTPRouteList
+	var grpcRoutes gatewayv1.GRPCRouteList
+	var tcpRoutes gatewayv1alpha2.TCPRouteList
+	var tlsRoutes gatewayv1alpha2.TLSRouteList
+	for _, list := range []struct {
+		list client.ObjectList
+		opts []client.ListOption
+	}{
+		{&gateways, nil},
+		{&gatewayClasses, nil},
+		{&grants, nil},
+		{&httpRoutes, []client.ListOption{inNamespace}},
+		{&grpcRoutes, []client.ListOption{inNamespace}},
+		{&tcpRoutes, []client.ListOption{inNamespace}},
+		{&tlsRoutes, []client.ListOption{inNamespace}},
+	} {
+		if err := reader.List(ctx, list.list, list.opts...); err != nil && !meta.IsNoMatchError(err) {
+			return objs, fmt.Errorf("failed to list %T: %w", list.list, err)
+		}
+	}
+	objs.Gateways = gateways.Items
+	objs.GatewayClasses = gatewayClasses.Items
+	objs.ReferenceGrants = grants.Items
+	objs.Routes = append(objs.Routes, routeObjects(httpRoutes.Items)...)
+	objs.Routes = append(objs.Routes, routeObjects(grpcRoutes.Items)...)
+	objs.Routes = append(objs.Routes, routeObjects(tcpRoutes.Items)...)
+	objs.Routes = append(objs.Routes, routeObjects(tlsRoutes.Items)...)
+	return objs, nil
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// nodeShape is the kind of object a diagram node stands for, which sets its shape.
+type nodeShape int
+
+const (
+	shapeGateway nodeShape = iota
+	shapeHostname
+	shapeRule
+	shapeService
+	shapeWorkload
+)
+
+// dotShapes and mermaidShapes hold the node shapes in each format, the DOT
+// ones as shape and style and the Mermaid ones as the brackets opening and
+// closing the label.
+var (
+	dotShapes = map[nodeShape][2]string{
+		shapeGateway:  {"hexagon", ""},
+		shapeHostname: {"ellipse", ""},
+		shapeRule:     {"box", ""},
+		shapeService:  {"box", "rounded"},
+		shapeWorkload: {"box3d", ""},
+	}
+	mermaidShapes = map[nodeShape][2]string{
+		shapeGateway:  {"{{", "}}"},
+		shapeHostname: {"([", "])"},
+		shapeRule:     {"[", "]"},
+		shapeService:  {"(", ")"},
+		shapeWorkload: {"[[", "]]"},
+	}
+)
+
+// diagram is the graph a Topology is drawn as: hostnames, attached to their
+// Gateways, lead to route rules, which send traffic to backend Services,
+// which select the Pods of workloads. Broken references are drawn as dashed
+// red edges labeled with their reason, and missing Gateways and Services as
+// dashed red nodes.
+type diagram struct {
+	namespaces []string
+	nodes      []*diagramNode
+	nodeKeys   map[string]*diagramNode
+	edges      []*diagramEdge
+	edgeKeys   map[[2]*diagramNode]*diagramEdge
+}
+
+type diagramNode struct {
+	id        string
+	label     string
+	namespace string
+	shape     nodeShape
+	broken    bool
+}
+
+type diagramEdge struct {
+	from, to *diagramNode
+	label    string
+	broken   bool
+}
+
+// node returns the node of an object, adding it on first use. Nodes with a
+// namespace are drawn in the cluster of their namespace.
+func (d *diagram) node(key, label, namespace string, shape nodeShape) *diagramNode {
+	if node, found := d.nodeKeys[key]; found {
+		return node
+	}
+	node := &diagramNode{id: fmt.Sprintf("n%d", len(d.nodes)), label: label, namespace: namespace, shape: shape}
+	d.nodes = append(d.nodes, node)
+	d.nodeKeys[key] = node
+	return node
+}
+
+// edge adds an edge, merging it with an existing edge between the same nodes.
+func (d *diagram) edge(from, to *diagramNode, label string, broken bool) {
+	if edge, found := d.edgeKeys[[2]*diagramNode{from, to}]; found {
+		if broken && !edge.broken {
+			edge.label, edge.broken = label, true
+		}
+		return
+	}
+	edge := &diagramEdge{from: from, to: to, label: label, broken: broken}
+	d.edges = append(d.edges, edge)
+	d.edgeKeys[[2]*diagramNode{from, to}] = edge
+}
+
+// newDiagram lays out the graph of a Topology.
+func newDiagram(t Topology) *diagram {
+	d := &diagram{nodeKeys: make(map[string]*diagramNode), edgeKeys: make(map[[2]*diagramNode]*diagramEdge)}
+	for _, ns := range t.Namespaces {
+		d.namespaces = append(d.namespaces, ns.Name)
+		for _, route := range ns.Routes {
+			hostnames := route.Hostnames
+			if len(hostnames) == 0 {
+				hostnames = []string{"*"}
+			}
+			var hosts []*diagramNode
+			for _, hostname := range hostnames {
+				hosts = append(hosts, d.node("hostname/"+hostname, hostname, "", shapeHostname))
+			}
+
+			for _, parent := range route.Parents {
+				gateway := d.node("gateway/"+parent.Gateway, "Gateway "+parent.Gateway, "", shapeGateway)
+				label := parent.SectionName
+				if parent.Broken != nil {
+					label = fmt.Sprintf("%s %s: %s", route.Kind, route.Name, parent.Broken.Reason)
+					if parent.Broken.Reason == webappv1.ParentReasonGatewayNotFound {
+						gateway.broken = true
+					}
+				}
+				for _, host := range hosts {
+					d.edge(gateway, host, label, parent.Broken != nil)
+				}
+			}
+
+			for i, rule := range route.Rules {
+				label := strings.Join(append([]string{fmt.Sprintf("%s %s rules[%d]", route.Kind, route.Name, i)},
+					rule.Matches...), "\n")
+				ruleNode := d.node(fmt.Sprintf("rule/%s/%s/%s/%d", ns.Name, route.Kind, route.Name, i),
+					label, ns.Name, shapeRule)
+				for _, host := range hosts {
+					d.edge(host, ruleNode, "", false)
+				}
+
+				var total int32
+				for _, backend := range rule.Backends {
+					total += backend.Weight
+				}
+				for _, backend := range rule.Backends {
+					backendNode := d.backendNode(ns.Name, backend)
+					label := "0%"
+					if total > 0 {
+						label = fmt.Sprintf("%d%%", int64(backend.Weight)*100/int64(total))
+					}
+					if backend.Broken != nil {
+						label = fmt.Sprintf("%s %s", label, backend.Broken.Reason)
+					}
+					d.edge(ruleNode, backendNode, label, backend.Broken != nil)
+				}
+			}
+		}
+	}
+
+	// Only the Services routes send traffic to are drawn, with their workloads
+	for _, ns := range t.Namespaces {
+		for _, service := range ns.Services {
+			serviceNode, found := d.nodeKeys["Service/"+ns.Name+"/"+service.Name]
+			if !found {
+				continue
+			}
+			for _, workload := range service.Workloads {
+				d.edge(serviceNode, d.node("workload/"+ns.Name+"/"+workload, workload, ns.Name, shapeWorkload), "", false)
+			}
+		}
+	}
+	return d
+}
+
+// backendNode returns the node of the backend of a rule in namespace. Backends
+// of other namespaces are labeled with their namespace.
+func (d *diagram) backendNode(namespace string, backend BackendEdge) *diagramNode {
+	kind := backend.Kind
+	if kind == "" {
+		kind = "Service"
+	}
+	label := fmt.Sprintf("%s %s", kind, backend.service.Name)
+	if backend.service.Namespace != namespace {
+		label = fmt.Sprintf("%s %s", kind, backend.Name)
+	}
+	node := d.node(kind+"/"+backend.Name, label, backend.service.Namespace, shapeService)
+	if backend.Broken != nil && backend.Broken.Reason == webappv1.BackendReasonServiceNotFound {
+		node.broken = true
+	}
+	return node
+}
+
+// nodesByNamespace returns the nodes outside of the drawn namespaces and the
+// nodes of each drawn namespace.
+func (d *diagram) nodesByNamespace() ([]*diagramNode, map[string][]*diagramNode) {
+	drawn := make(map[string][]*diagramNode, len(d.namespaces))
+	for _, namespace := range d.namespaces {
+		drawn[namespace] = nil
+	}
+	var outside []*diagramNode
+	for _, node := range d.nodes {
+		if _, found := drawn[node.namespace]; found {
+			drawn[node.namespace] = append(drawn[node.namespace], node)
+		} else {
+			outside = append(outside, node)
+		}
+	}
+	return outside, drawn
+}
+
+// WriteDOT writes the Topology as a Graphviz DOT digraph, with a cluster per
+// namespace.
+func (t Topology) WriteDOT(w io.Writer) error {
+	d := newDiagram(t)
+	var b strings.Builder
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+	writeNode := func(indent string, node *diagramNode) {
+		shape, style := dotShapes[node.shape][0], dotShapes[node.shape][1]
+		attrs := "shape=" + shape
+		if node.broken {
+			style = strings.TrimPrefix(style+",dashed", ",")
+		}
+		if style != "" {
+			attrs += ", style=" + quote(style)
+		}
+		if node.broken {
+			attrs += ", color=red, fontcolor=red"
+		}
+		fmt.Fprintf(&b, "%s%s [label=%s, %s];\n", indent, node.id, quote(node.label), attrs)
+	}
+
+	b.WriteString("digraph topology {\n\trankdir=LR;\n")
+	outside, drawn := d.nodesByNamespace()
+	for _, node := range outside {
+		writeNode("\t", node)
+	}
+	for _, namespace := range d.namespaces {
+		fmt.Fprintf(&b, "\tsubgraph %s {\n\t\tlabel=%s;\n", quote("cluster_"+namespace), quote("namespace "+namespace))
+		for _, node := range drawn[namespace] {
+			writeNode("\t\t", node)
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, edge := range d.edges {
+		var attrs []string
+		if edge.label != "" {
+			attrs = append(attrs, "label="+quote(edge.label))
+		}
+		if edge.broken {
+			attrs = append(attrs, "color=red", "fontcolor=red", "style=dashed")
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", edge.from.id, edge.to.id)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the Topology as a Mermaid flowchart, with a subgraph per
+// namespace.
+func (t Topology) WriteMermaid(w io.Writer) error {
+	d := newDiagram(t)
+	var b strings.Builder
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(s) + `"`
+	}
+	writeNode := func(indent string, node *diagramNode) {
+		shape := mermaidShapes[node.shape]
+		fmt.Fprintf(&b, "%s%s%s%s%s\n", indent, node.id, shape[0], quote(node.label), shape[1])
+	}
+
+	b.WriteString("flowchart LR\n")
+	outside, drawn := d.nodesByNamespace()
+	for _, node := range outside {
+		writeNode("  ", node)
+	}
+	for i, namespace := range d.namespaces {
+		fmt.Fprintf(&b, "  subgraph ns%d [%s]\n", i, quote("namespace "+namespace))
+		for _, node := range drawn[namespace] {
+			writeNode("    ", node)
+		}
+		b.WriteString("  end\n")
+	}
+	var brokenEdges []string
+	for i, edge := range d.edges {
+		arrow := "-->"
+		if edge.broken {
+			arrow = "-.->"
+			brokenEdges = append(brokenEdges, fmt.Sprint(i))
+		}
+		if edge.label != "" {
+			arrow += "|" + quote(edge.label) + "|"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", edge.from.id, arrow, edge.to.id)
+	}
+
+	b.WriteString("  classDef broken stroke:#d32f2f,stroke-dasharray:5 5,color:#d32f2f\n")
+	for _, node := range d.nodes {
+		if node.broken {
+			fmt.Fprintf(&b, "  class %s broken\n", node.id)
+		}
+	}
+	if len(brokenEdges) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#d32f2f,color:#d32f2f\n", strings.Join(brokenEdges, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// Severities of a Finding.
+const (
+	// SeverityError marks a reference that does not carry traffic.
+	SeverityError = "error"
+	// SeverityWarning marks an object that is likely misconfigured.
+	SeverityWarning = "warning"
+)
+
+// ReasonNoWorkload is the reason of Services whose selector matches neither
+// endpoints nor Deployments.
+const ReasonNoWorkload = "NoWorkload"
+
+// Finding is a problem found in a Topology or in the objects it was built from.
+type Finding struct {
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+}
+
+// String formats a finding as "severity: Kind namespace/name: Reason: message".
+func (f Finding) String() string {
+	name := f.Name
+	if f.Namespace != "" {
+		name = f.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s: %s %s: %s: %s", f.Severity, f.Kind, name, f.Reason, f.Message)
+}
+
+// Findings returns the broken parentRefs and backendRefs of the routes of a
+// Topology as errors, and the Services with a selector matching neither
+// endpoints nor Deployments as warnings.
+func (t Topology) Findings() []Finding {
+	var findings []Finding
+	for _, ns := range t.Namespaces {
+		for _, route := range ns.Routes {
+			finding := Finding{Severity: SeverityError, Kind: route.Kind, Namespace: ns.Name, Name: route.Name}
+			for _, parent := range route.Parents {
+				if parent.Broken != nil {
+					finding.Reason, finding.Message = parent.Broken.Reason, parent.Broken.Message
+					findings = append(findings, finding)
+				}
+			}
+			for _, rule := range route.Rules {
+				for _, backend := range rule.Backends {
+					if backend.Broken != nil {
+						finding.Reason, finding.Message = backend.Broken.Reason, backend.Broken.Message
+						findings = append(findings, finding)
+					}
+				}
+			}
+		}
+		for _, service := range ns.Services {
+			if len(service.Selector) > 0 && len(service.Endpoints) == 0 && len(service.Workloads) == 0 {
+				findings = append(findings, Finding{
+					Severity:  SeverityWarning,
+					Kind:      "Service",
+					Namespace: ns.Name,
+					Name:      service.Name,
+					Reason:    ReasonNoWorkload,
+					Message: fmt.Sprintf("selector %s matches no endpoints and no Deployment",
+						formatSelector(service.Selector)),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// formatSelector formats a label selector the way kubectl takes it.
+func formatSelector(selector map[string]string) string {
+	terms := make([]string, 0, len(selector))
+	for key, value := range selector {
+		terms = append(terms, key+"="+value)
+	}
+	slices.Sort(terms)
+	return strings.Join(terms, ",")
+}
+
+// AddGenerated adds the objects the controller generates for a Myapp, as
+// they are written when no rollout is in progress, so that references to them
+// resolve without a cluster. Objects of the same kind and name that were given
+// are kept instead, as they describe the live state.
+func (o *TopologyObjects) AddGenerated(myapp *webappv1.Myapp) {
+	for _, obj := range generatedObjects(myapp) {
+		key := client.ObjectKeyFromObject(obj)
+		switch obj := obj.(type) {
+		case *appsv1.Deployment:
+			if !slices.ContainsFunc(o.Deployments, func(d appsv1.Deployment) bool {
+				return client.ObjectKeyFromObject(&d) == key
+			}) {
+				o.Deployments = append(o.Deployments, *obj)
+			}
+		case *corev1.Service:
+			if !slices.ContainsFunc(o.Services, func(s corev1.Service) bool {
+				return client.ObjectKeyFromObject(&s) == key
+			}) {
+				o.Services = append(o.Services, *obj)
+			}
+		default:
+			if !slices.ContainsFunc(o.Routes, func(route client.Object) bool {
+				return routeKindOf(route) == routeKindOf(obj) && client.ObjectKeyFromObject(route) == key
+			}) {
+				o.Routes = append(o.Routes, obj)
+			}
+		}
+	}
+}
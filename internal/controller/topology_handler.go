@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TopologyPath is the path the manager serves the Topology on.
+const TopologyPath = "/topology"
+
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// topologyTimeout bounds reading the Topology, which waits for the informers
+// of kinds not read by the controllers, such as EndpointSlices, to start.
+const topologyTimeout = 30 * time.Second
+
+// Formats the Topology is served in, selected by the format query parameter.
+const (
+	TopologyFormatJSON    = "json"
+	TopologyFormatDOT     = "dot"
+	TopologyFormatMermaid = "mermaid"
+)
+
+// TopologyHandler serves the Topology of every namespace, or of the namespace
+// given by the namespace query parameter, as JSON or, with the format query
+// parameter, as a Graphviz DOT or Mermaid diagram. It reads the objects from
+// Reader, usually the informer cache of the manager, and never writes.
+type TopologyHandler struct {
+	Reader client.Reader
+	// APIReader reads the backend Services kept out of the cache by
+	// ServiceFilter, so that they are not drawn as missing.
+	APIReader     client.Reader
+	ServiceFilter WatchFilter
+}
+
+// ServeHTTP implements http.Handler.
+func (h *TopologyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	log := logf.FromContext(req.Context())
+
+	format := req.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = TopologyFormatJSON
+	case TopologyFormatJSON, TopologyFormatDOT, TopologyFormatMermaid:
+	default:
+		http.Error(w, fmt.Sprintf("unsupported format %q: expected %s, %s or %s",
+			format, TopologyFormatJSON, TopologyFormatDOT, TopologyFormatMermaid), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), topologyTimeout)
+	defer cancel()
+	namespace := req.URL.Query().Get("namespace")
+	objs, err := ListTopologyObjects(ctx, h.Reader, namespace)
+	if err != nil {
+		log.Error(err, "Failed to read the topology")
+		status := http.StatusInternalServerError
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	var keys []types.NamespacedName
+	for _, route := range objs.Routes {
+		keys = append(keys, backendServiceKeys(route)...)
+	}
+	objs.Services = readFilteredServices(ctx, h.APIReader, h.ServiceFilter, keys, objs.Services)
+	topology := BuildTopology(objs)
+	if namespace != metav1.NamespaceAll {
+		topology = topology.ForNamespace(namespace)
+	}
+
+	switch format {
+	case TopologyFormatDOT:
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		err = topology.WriteDOT(w)
+	case TopologyFormatMermaid:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		err = topology.WriteMermaid(w)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(topology)
+	}
+	if err != nil {
+		log.Error(err, "Failed to write the topology")
+	}
+}
+
+// ForNamespace returns the Topology of a single namespace. Nodes of other
+// namespaces, such as the Gateways its routes attach to, are dropped; the
+// edges pointing at them are kept.
+func (t Topology) ForNamespace(namespace string) Topology {
+	filtered := Topology{Namespaces: []NamespaceTopology{}}
+	for _, ns := range t.Namespaces {
+		if ns.Name == namespace {
+			filtered.Namespaces = append(filtered.Namespaces, ns)
+		}
+	}
+	return filtered
+}
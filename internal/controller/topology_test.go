@@ -0,0 +1,392 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayapischeme "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/scheme"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+var _ = Describe("Topology", func() {
+	var objs TopologyObjects
+
+	backendRef := func(namespace, name string, port, weight int32) gatewayv1.HTTPBackendRef {
+		ref := gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{
+			BackendObjectReference: gatewayv1.BackendObjectReference{
+				Name: gatewayv1.ObjectName(name),
+				Port: ptr.To(gatewayv1.PortNumber(port)),
+			},
+			Weight: ptr.To(weight),
+		}}
+		if namespace != "" {
+			ref.Namespace = ptr.To(gatewayv1.Namespace(namespace))
+		}
+		return ref
+	}
+
+	BeforeEach(func() {
+		objs = TopologyObjects{
+			Gateways: []gatewayv1.Gateway{{
+				ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "tns"},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "nginx",
+					Listeners: []gatewayv1.Listener{{
+						Name:     "http",
+						Protocol: gatewayv1.HTTPProtocolType,
+						Port:     80,
+						Hostname: ptr.To(gatewayv1.Hostname("*.my-apps.com")),
+					}},
+				},
+			}},
+			GatewayClasses: []gatewayv1.GatewayClass{{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}}},
+			Routes: []client.Object{&gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "tns", Labels: map[string]string{myappLabel: "shop"}},
+				Spec: gatewayv1.HTTPRouteSpec{
+					CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{
+						{Name: "public"},
+						{Name: "missing"},
+						{Name: "edge", Namespace: ptr.To(gatewayv1.Namespace("infra"))},
+					}},
+					Hostnames: []gatewayv1.Hostname{"shop.my-apps.com"},
+					Rules: []gatewayv1.HTTPRouteRule{
+						{
+							Matches: []gatewayv1.HTTPRouteMatch{{
+								Path: &gatewayv1.HTTPPathMatch{
+									Type:  ptr.To(gatewayv1.PathMatchPathPrefix),
+									Value: ptr.To("/api"),
+								},
+								Method: ptr.To(gatewayv1.HTTPMethodGet),
+							}},
+							BackendRefs: []gatewayv1.HTTPBackendRef{
+								backendRef("", "api", 9090, 90),
+								backendRef("", "api-canary", 9090, 10),
+							},
+						},
+						{
+							BackendRefs: []gatewayv1.HTTPBackendRef{
+								backendRef("", "web", 80, 1),
+								backendRef("shared", "assets", 80, 1),
+							},
+						},
+					},
+				},
+			}},
+			Services: []corev1.Service{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "tns", Labels: map[string]string{myappLabel: "shop"}},
+					Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 9090}}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "tns"},
+					Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+				},
+			},
+			EndpointSlices: []discoveryv1.EndpointSlice{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "api-x7k2p",
+					Namespace: "tns",
+					Labels:    map[string]string{discoveryv1.LabelServiceName: "api"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{
+					{
+						Addresses:  []string{"10.0.0.1"},
+						Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+						TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "api-0"},
+					},
+					{
+						Addresses:  []string{"10.0.0.2"},
+						Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)},
+						TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "api-1"},
+					},
+				},
+			}},
+			ReferenceGrants: []gatewayv1beta1.ReferenceGrant{{
+				ObjectMeta: metav1.ObjectMeta{Name: "from-tns", Namespace: "shared"},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{
+						Group: gatewayv1.GroupName, Kind: "HTTPRoute", Namespace: "tns",
+					}},
+					To: []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service"}},
+				},
+			}},
+			Myapps: []webappv1.Myapp{{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "tns"},
+				Status: webappv1.MyappStatus{
+					URL: "http://shop.my-apps.com",
+					Conditions: []metav1.Condition{{
+						Type: webappv1.ConditionReady, Status: metav1.ConditionTrue, Reason: webappv1.ReasonAvailable,
+					}},
+				},
+			}},
+		}
+	})
+
+	It("should build the graph of a namespace with its broken edges", func() {
+		topology := BuildTopology(objs)
+		Expect(topology.Namespaces).To(HaveLen(1))
+		ns := topology.Namespaces[0]
+		Expect(ns.Name).To(Equal("tns"))
+
+		Expect(ns.Gateways).To(Equal([]GatewayNode{{
+			Name:             "public",
+			GatewayClassName: "nginx",
+			Listeners:        []ListenerNode{{Name: "http", Protocol: "HTTP", Port: 80, Hostname: "*.my-apps.com"}},
+		}}))
+		Expect(ns.Myapps).To(Equal([]MyappNode{{Name: "shop", Ready: "True", URL: "http://shop.my-apps.com"}}))
+
+		By("linking Services to the endpoints of their EndpointSlices")
+		Expect(ns.Services).To(Equal([]ServiceNode{
+			{
+				Name:  "api",
+				Myapp: "shop",
+				Ports: []int32{9090},
+				Endpoints: []EndpointNode{
+					{Address: "10.0.0.1", Pod: "api-0", Ready: true},
+					{Address: "10.0.0.2", Pod: "api-1", Ready: false},
+				},
+			},
+			{Name: "web", Ports: []int32{8080}},
+		}))
+
+		By("marking the parentRefs to missing Gateways, but not those of namespaces it was not given")
+		Expect(ns.Routes).To(HaveLen(1))
+		route := ns.Routes[0]
+		Expect(route.Kind).To(Equal("HTTPRoute"))
+		Expect(route.Myapp).To(Equal("shop"))
+		Expect(route.Hostnames).To(Equal([]string{"shop.my-apps.com"}))
+		Expect(route.Parents).To(HaveLen(3))
+		Expect(route.Parents[0]).To(Equal(ParentEdge{Gateway: "tns/public"}))
+		Expect(route.Parents[1].Gateway).To(Equal("tns/missing"))
+		Expect(route.Parents[1].Broken).NotTo(BeNil())
+		Expect(route.Parents[1].Broken.Reason).To(Equal(webappv1.ParentReasonGatewayNotFound))
+		Expect(route.Parents[2]).To(Equal(ParentEdge{Gateway: "infra/edge"}))
+
+		By("marking the backendRefs to missing Services and ports, with their weights")
+		Expect(route.Rules).To(HaveLen(2))
+		Expect(route.Rules[0].Matches).To(Equal([]string{"GET PathPrefix /api"}))
+		backends := route.Rules[0].Backends
+		Expect(backends).To(HaveLen(2))
+		Expect(backends[0].Name).To(Equal("tns/api"))
+		Expect(backends[0].Weight).To(BeEquivalentTo(90))
+		Expect(backends[0].Broken).To(BeNil())
+		Expect(backends[1].Name).To(Equal("tns/api-canary"))
+		Expect(backends[1].Weight).To(BeEquivalentTo(10))
+		Expect(backends[1].Broken).NotTo(BeNil())
+		Expect(backends[1].Broken.Reason).To(Equal(webappv1.BackendReasonServiceNotFound))
+
+		Expect(route.Rules[1].Matches).To(Equal([]string{"PathPrefix /"}))
+		backends = route.Rules[1].Backends
+		Expect(backends).To(HaveLen(2))
+		Expect(backends[0].Broken).NotTo(BeNil())
+		Expect(backends[0].Broken.Reason).To(Equal(webappv1.BackendReasonPortNotFound))
+		Expect(backends[1].Name).To(Equal("shared/assets"))
+		Expect(backends[1].Broken).To(BeNil())
+	})
+
+	It("should check the objects generated for a Myapp without a cluster", func() {
+		objs.Routes = nil
+		objs.Services = append(objs.Services, corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: "tns"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "gone"},
+				Ports:    []corev1.ServicePort{{Port: 80}},
+			},
+		})
+		objs.Myapps[0].Spec = webappv1.MyappSpec{
+			Image: "nginx:1.27",
+			Routing: &webappv1.RoutingSpec{
+				ParentRefs:  []webappv1.ParentRef{{Name: "public"}},
+				Hostnames:   []string{"shop.my-apps.com"},
+				BackendRefs: []webappv1.BackendRef{{Name: "legacy", Port: 80}},
+			},
+		}
+		objs.AddGenerated(&objs.Myapps[0])
+		Expect(objs.Deployments).To(HaveLen(1))
+		Expect(objs.Routes).To(HaveLen(1))
+		Expect(objs.Services).To(HaveLen(4))
+
+		By("keeping the objects that were given")
+		objs.AddGenerated(&objs.Myapps[0])
+		Expect(objs.Deployments).To(HaveLen(1))
+		Expect(objs.Routes).To(HaveLen(1))
+		Expect(objs.Services).To(HaveLen(4))
+
+		topology := BuildTopology(objs)
+		ns := topology.Namespaces[0]
+		Expect(ns.Services).To(ContainElement(And(
+			HaveField("Name", "shop"),
+			HaveField("Myapp", "shop"),
+			HaveField("Workloads", []string{"Deployment/shop"}),
+		)))
+		Expect(topology.Findings()).To(ConsistOf(
+			Finding{
+				Severity: SeverityError, Kind: "HTTPRoute", Namespace: "tns", Name: "shop",
+				Reason: webappv1.BackendReasonServiceNotFound, Message: "Service tns/legacy does not exist",
+			},
+			Finding{
+				Severity: SeverityWarning, Kind: "Service", Namespace: "tns", Name: "orphan",
+				Reason: ReasonNoWorkload, Message: "selector app=gone matches no endpoints and no Deployment",
+			},
+		))
+	})
+
+	It("should draw the graph as DOT and Mermaid with its broken edges", func() {
+		objs.Deployments = []appsv1.Deployment{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "tns"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "api"}},
+			}},
+		}}
+		objs.Services[0].Spec.Selector = map[string]string{"app": "api"}
+		topology := BuildTopology(objs)
+
+		var dot strings.Builder
+		Expect(topology.WriteDOT(&dot)).To(Succeed())
+		Expect(dot.String()).To(HavePrefix("digraph topology {\n"))
+		Expect(dot.String()).To(ContainSubstring(`subgraph "cluster_tns" {`))
+		Expect(dot.String()).To(ContainSubstring(`n0 [label="shop.my-apps.com", shape=ellipse];`))
+		Expect(dot.String()).To(ContainSubstring(`n1 [label="Gateway tns/public", shape=hexagon];`))
+		Expect(dot.String()).To(ContainSubstring(
+			`n2 [label="Gateway tns/missing", shape=hexagon, style="dashed", color=red, fontcolor=red];`))
+		Expect(dot.String()).To(ContainSubstring(
+			`n2 -> n0 [label="HTTPRoute shop: GatewayNotFound", color=red, fontcolor=red, style=dashed];`))
+		Expect(dot.String()).To(ContainSubstring(`n4 [label="HTTPRoute shop rules[0]\nGET PathPrefix /api", shape=box];`))
+		Expect(dot.String()).To(ContainSubstring(`n4 -> n5 [label="90%"];`))
+		Expect(dot.String()).To(ContainSubstring(
+			`n6 [label="Service api-canary", shape=box, style="rounded,dashed", color=red, fontcolor=red];`))
+		Expect(dot.String()).To(ContainSubstring(
+			`n4 -> n6 [label="10% ServiceNotFound", color=red, fontcolor=red, style=dashed];`))
+		Expect(dot.String()).To(ContainSubstring(
+			`n7 -> n8 [label="50% PortNotFound", color=red, fontcolor=red, style=dashed];`))
+		Expect(dot.String()).To(ContainSubstring(`n9 [label="Service shared/assets", shape=box, style="rounded"];`))
+		Expect(dot.String()).To(ContainSubstring(`n5 -> n10;`))
+		Expect(dot.String()).To(ContainSubstring(`n10 [label="Deployment/api", shape=box3d];`))
+
+		var mermaid strings.Builder
+		Expect(topology.WriteMermaid(&mermaid)).To(Succeed())
+		Expect(mermaid.String()).To(HavePrefix("flowchart LR\n"))
+		Expect(mermaid.String()).To(ContainSubstring(`subgraph ns0 ["namespace tns"]`))
+		Expect(mermaid.String()).To(ContainSubstring(`n1{{"Gateway tns/public"}}`))
+		Expect(mermaid.String()).To(ContainSubstring(`n4["HTTPRoute shop rules[0]<br/>GET PathPrefix /api"]`))
+		Expect(mermaid.String()).To(ContainSubstring(`n4 -->|"90%"| n5`))
+		Expect(mermaid.String()).To(ContainSubstring(`n4 -.->|"10% ServiceNotFound"| n6`))
+		Expect(mermaid.String()).To(ContainSubstring(`n10[["Deployment/api"]]`))
+		Expect(mermaid.String()).To(ContainSubstring("class n2 broken\n  class n6 broken\n"))
+		Expect(mermaid.String()).To(ContainSubstring("linkStyle 1,5,7 stroke:#d32f2f"))
+	})
+
+	It("should serve the graph as JSON from the cache", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(gatewayapischeme.AddToScheme(scheme)).To(Succeed())
+		Expect(webappv1.AddToScheme(scheme)).To(Succeed())
+
+		var clientObjs []client.Object
+		clientObjs = append(clientObjs, &objs.Gateways[0], &objs.GatewayClasses[0], objs.Routes[0])
+		clientObjs = append(clientObjs, &objs.Services[0], &objs.Services[1], &objs.EndpointSlices[0])
+		clientObjs = append(clientObjs, &objs.ReferenceGrants[0], &objs.Myapps[0])
+		clientObjs = append(clientObjs, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default"},
+		})
+		handler := &TopologyHandler{
+			Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(clientObjs...).Build(),
+		}
+
+		By("serving every namespace")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath, nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+		var topology Topology
+		Expect(json.Unmarshal(response.Body.Bytes(), &topology)).To(Succeed())
+		Expect(topology.Namespaces).To(HaveLen(2))
+		Expect(topology.Namespaces[0].Name).To(Equal("default"))
+
+		By("serving a single namespace")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath+"?namespace=tns", nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		topology = Topology{}
+		Expect(json.Unmarshal(response.Body.Bytes(), &topology)).To(Succeed())
+		Expect(topology.Namespaces).To(HaveLen(1))
+		ns := topology.Namespaces[0]
+		Expect(ns.Name).To(Equal("tns"))
+		Expect(ns.Routes).To(HaveLen(1))
+		Expect(ns.Routes[0].Rules[0].Backends[1].Broken).NotTo(BeNil())
+		Expect(ns.Services[0].Endpoints).To(HaveLen(2))
+
+		By("reading backend Services kept out of the cache")
+		filter, err := ParseWatchFilter(managedLabel+"=true", "")
+		Expect(err).NotTo(HaveOccurred())
+		filtered := &TopologyHandler{
+			Reader: handler.Reader,
+			APIReader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-canary", Namespace: "tns"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 9090}}},
+			}).Build(),
+			ServiceFilter: filter,
+		}
+		response = httptest.NewRecorder()
+		filtered.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath+"?namespace=tns", nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		topology = Topology{}
+		Expect(json.Unmarshal(response.Body.Bytes(), &topology)).To(Succeed())
+		Expect(topology.Namespaces[0].Routes[0].Rules[0].Backends[1].Broken).To(BeNil())
+
+		By("serving diagrams")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath+"?namespace=tns&format=dot", nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("Content-Type")).To(HavePrefix("text/vnd.graphviz"))
+		Expect(response.Body.String()).To(ContainSubstring(`subgraph "cluster_tns"`))
+		Expect(response.Body.String()).NotTo(ContainSubstring("cluster_default"))
+
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath+"?format=mermaid", nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(HavePrefix("flowchart LR\n"))
+
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, TopologyPath+"?format=svg", nil))
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+		By("rejecting writes")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, TopologyPath, nil))
+		Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
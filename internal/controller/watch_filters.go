@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// WatchFilter restricts the objects of a watched kind that are cached and
+// trigger reconciles. The zero value lets every object through.
+type WatchFilter struct {
+	// Label selects objects by their labels, in the cache and for events.
+	Label labels.Selector
+	// Field selects objects by their fields. It is only applied by the API
+	// server when filling the cache.
+	Field fields.Selector
+}
+
+// ParseWatchFilter parses the label and field selectors of a WatchFilter.
+// Empty selectors select everything.
+func ParseWatchFilter(labelSelector, fieldSelector string) (WatchFilter, error) {
+	var filter WatchFilter
+	if labelSelector != "" {
+		selector, err := labels.Parse(labelSelector)
+		if err != nil {
+			return WatchFilter{}, fmt.Errorf("failed to parse label selector %q: %w", labelSelector, err)
+		}
+		filter.Label = selector
+	}
+	if fieldSelector != "" {
+		selector, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return WatchFilter{}, fmt.Errorf("failed to parse field selector %q: %w", fieldSelector, err)
+		}
+		filter.Field = selector
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter lets every object through.
+func (f WatchFilter) IsEmpty() bool {
+	return (f.Label == nil || f.Label.Empty()) && (f.Field == nil || f.Field.Empty())
+}
+
+// ByObject returns the cache configuration applying the filter.
+func (f WatchFilter) ByObject() cache.ByObject {
+	return cache.ByObject{Label: f.Label, Field: f.Field}
+}
+
+// predicate drops the events of objects whose labels do not match the filter.
+func (f WatchFilter) predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return f.Label == nil || f.Label.Matches(labels.Set(obj.GetLabels()))
+	})
+}
+
+// validate checks that the filter keeps the objects generated for Myapps,
+// which the controller must be able to read back from the cache.
+func (f WatchFilter) validate(kind string) error {
+	if f.Label == nil {
+		return nil
+	}
+	generated := labelsFor(&webappv1.Myapp{})
+	if !f.Label.Matches(labels.Set(generated)) {
+		return fmt.Errorf("%s label selector %q does not match the labels of generated objects, "+
+			"select them with %s=true", kind, f.Label, managedLabel)
+	}
+	return nil
+}
+
+// readFilteredServices adds to services the backends of the routes that are
+// missing from them because ServiceFilter kept them out of the cache. They are
+// read from the API server, so that they are not reported as missing.
+func (r *MyappReconciler) readFilteredServices(
+	ctx context.Context, routes []client.Object, services []corev1.Service,
+) []corev1.Service {
+	var keys []types.NamespacedName
+	for _, route := range routes {
+		keys = append(keys, backendServiceKeys(route)...)
+	}
+	return readFilteredServices(ctx, r.APIReader, r.ServiceFilter, keys, services)
+}
+
+// readFilteredServices adds to services those of the given keys that are
+// missing from them because filter kept them out of the cache, reading them
+// with apiReader.
+func readFilteredServices(
+	ctx context.Context, apiReader client.Reader, filter WatchFilter,
+	keys []types.NamespacedName, services []corev1.Service,
+) []corev1.Service {
+	if filter.IsEmpty() || apiReader == nil {
+		return services
+	}
+
+	known := make(map[types.NamespacedName]bool, len(services))
+	for i := range services {
+		known[client.ObjectKeyFromObject(&services[i])] = true
+	}
+	for _, key := range keys {
+		if known[key] {
+			continue
+		}
+		known[key] = true
+
+		var service corev1.Service
+		if err := apiReader.Get(ctx, key, &service); err != nil {
+			if !apierrors.IsNotFound(err) {
+				logf.FromContext(ctx).Info("Failed to read backend Service", "service", key, "error", err)
+			}
+			continue
+		}
+		services = append(services, service)
+	}
+	return services
+}
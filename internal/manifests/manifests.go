@@ -0,0 +1,246 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifests reads the objects the controller checks from manifest
+// files, so that they can be checked without a cluster.
+package manifests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+	webappv2 "my-apps.com/myapp/api/v2"
+	"my-apps.com/myapp/internal/controller"
+)
+
+// Stdin is the path Load reads from standard input, for instance to check the
+// output of kustomize build.
+const Stdin = "-"
+
+// Manifests holds the objects read from manifest files.
+type Manifests struct {
+	// Objects holds the objects of the kinds making up a Topology.
+	Objects controller.TopologyObjects
+	// Skipped counts the objects of other kinds.
+	Skipped int
+
+	namespace string
+	sources   map[objectRef]string
+}
+
+// objectRef identifies an object across kinds.
+type objectRef struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// Source returns the file an object was read from, or "" if it was not read.
+func (m *Manifests) Source(kind, namespace, name string) string {
+	return m.sources[objectRef{kind, namespace, name}]
+}
+
+// Load reads the YAML and JSON manifests in the given files and, recursively,
+// directories, or in standard input for Stdin. Files and directories whose name
+// starts with a dot are skipped. Namespaced objects that set no namespace are
+// put in namespace. Objects of kinds not making up a Topology are skipped; an
+// object defined twice is an error.
+func Load(namespace string, paths ...string) (*Manifests, error) {
+	m := &Manifests{namespace: namespace, sources: make(map[objectRef]string)}
+	for _, path := range paths {
+		if path == Stdin {
+			if err := m.decode("<stdin>", os.Stdin); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if file != path && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() || !isManifest(file) {
+				return nil
+			}
+			return m.decodeFile(file)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// isManifest reports whether a file holds manifests, by its extension.
+func isManifest(file string) bool {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeFile reads the manifests of a file.
+func (m *Manifests) decodeFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer f.Close() //nolint:errcheck
+	return m.decode(file, f)
+}
+
+// decode reads the YAML documents or JSON objects of a stream.
+func (m *Manifests) decode(source string, r io.Reader) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var obj map[string]any
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode %s: %w", source, err)
+		}
+		if obj == nil {
+			continue
+		}
+		if err := m.add(source, &unstructured.Unstructured{Object: obj}); err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+	}
+}
+
+// add adds an object, or the items of a list, to the Topology objects.
+// Gateway API objects are read into the types of the version the controller
+// uses whatever their version, since the Gateway API keeps the schema of a kind
+// identical across the versions it serves.
+func (m *Manifests) add(source string, u *unstructured.Unstructured) error {
+	if u.IsList() {
+		return u.EachListItem(func(item runtime.Object) error {
+			return m.add(source, item.(*unstructured.Unstructured))
+		})
+	}
+
+	objs := &m.Objects
+	gvk := u.GroupVersionKind()
+	var obj client.Object
+	var err error
+	switch gvk.GroupKind() {
+	case schema.GroupKind{Group: corev1.GroupName, Kind: "Service"}:
+		obj, err = appendObject(u, &objs.Services)
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}:
+		obj, err = appendObject(u, &objs.Deployments)
+	case schema.GroupKind{Group: discoveryv1.GroupName, Kind: "EndpointSlice"}:
+		obj, err = appendObject(u, &objs.EndpointSlices)
+	case schema.GroupKind{Group: corev1.GroupName, Kind: "Namespace"}:
+		obj, err = appendObject(u, &objs.Namespaces)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "GatewayClass"}:
+		obj, err = appendObject(u, &objs.GatewayClasses)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "Gateway"}:
+		obj, err = appendObject(u, &objs.Gateways)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "ReferenceGrant"}:
+		obj, err = appendObject(u, &objs.ReferenceGrants)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "HTTPRoute"}:
+		obj, err = appendRoute(u, &gatewayv1.HTTPRoute{}, &objs.Routes)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "GRPCRoute"}:
+		obj, err = appendRoute(u, &gatewayv1.GRPCRoute{}, &objs.Routes)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "TCPRoute"}:
+		obj, err = appendRoute(u, &gatewayv1alpha2.TCPRoute{}, &objs.Routes)
+	case schema.GroupKind{Group: gatewayv1.GroupName, Kind: "TLSRoute"}:
+		obj, err = appendRoute(u, &gatewayv1alpha2.TLSRoute{}, &objs.Routes)
+	case webappv1.GroupVersion.WithKind("Myapp").GroupKind():
+		obj, err = m.appendMyapp(u)
+	default:
+		m.Skipped++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s %s: %w", gvk.Kind, u.GetName(), err)
+	}
+
+	if obj.GetNamespace() == "" && gvk.Kind != "Namespace" && gvk.Kind != "GatewayClass" {
+		obj.SetNamespace(m.namespace)
+	}
+	ref := objectRef{gvk.Kind, obj.GetNamespace(), obj.GetName()}
+	if previous, found := m.sources[ref]; found {
+		return fmt.Errorf("%s %s is also defined in %s", gvk.Kind, client.ObjectKeyFromObject(obj), previous)
+	}
+	m.sources[ref] = source
+	return nil
+}
+
+// appendMyapp appends a Myapp to the Topology objects, converting Myapps of
+// other versions to the version the controller uses.
+func (m *Manifests) appendMyapp(u *unstructured.Unstructured) (client.Object, error) {
+	if u.GroupVersionKind().Version != webappv2.GroupVersion.Version {
+		return appendObject(u, &m.Objects.Myapps)
+	}
+	var v2 webappv2.Myapp
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &v2); err != nil {
+		return nil, err
+	}
+	var myapp webappv1.Myapp
+	if err := v2.ConvertTo(&myapp); err != nil {
+		return nil, err
+	}
+	m.Objects.Myapps = append(m.Objects.Myapps, myapp)
+	return &m.Objects.Myapps[len(m.Objects.Myapps)-1], nil
+}
+
+// appendObject reads an object into a new element of objs and returns it.
+func appendObject[T any, PT interface {
+	*T
+	client.Object
+}](u *unstructured.Unstructured, objs *[]T) (client.Object, error) {
+	var obj T
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &obj); err != nil {
+		return nil, err
+	}
+	*objs = append(*objs, obj)
+	return PT(&(*objs)[len(*objs)-1]), nil
+}
+
+// appendRoute reads a route into route and appends it to routes.
+func appendRoute(u *unstructured.Unstructured, route client.Object, routes *[]client.Object) (client.Object, error) {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, route); err != nil {
+		return nil, err
+	}
+	*routes = append(*routes, route)
+	return route, nil
+}
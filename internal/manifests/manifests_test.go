@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestManifests(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Manifests Suite")
+}
+
+var _ = Describe("Load", func() {
+	var dir string
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should read the objects of every manifest under a directory", func() {
+		write("apps/shop.yaml", `
+apiVersion: webapp.my-apps.com/v2
+kind: Myapp
+metadata:
+  name: shop
+spec:
+  workload:
+    image: nginx:1.27
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: api
+    namespace: tns
+  spec:
+    ports:
+    - port: 9090
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: api
+    namespace: tns
+---
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- shop.yaml
+`)
+		write("infra/gateway.json", `{
+  "apiVersion": "gateway.networking.k8s.io/v1beta1",
+  "kind": "Gateway",
+  "metadata": {"name": "public", "namespace": "infra"},
+  "spec": {"gatewayClassName": "nginx", "listeners": [{"name": "http", "protocol": "HTTP", "port": 80}]}
+}`)
+		write("infra/class.yml", `
+apiVersion: gateway.networking.k8s.io/v1
+kind: GatewayClass
+metadata:
+  name: nginx
+spec:
+  controllerName: example.com/gateway
+`)
+		write("routes/api.yaml", `
+apiVersion: gateway.networking.k8s.io/v1
+kind: GRPCRoute
+metadata:
+  name: api
+  namespace: tns
+`)
+		write("README.md", "not a manifest")
+		write(".github/workflows/ci.yaml", "on: [push")
+
+		m, err := Load("apps", dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Skipped).To(Equal(1))
+
+		objs := m.Objects
+		Expect(objs.Myapps).To(HaveLen(1))
+		Expect(objs.Myapps[0].Namespace).To(Equal("apps"))
+		Expect(objs.Myapps[0].Spec.Image).To(Equal("nginx:1.27"))
+		Expect(objs.Services).To(HaveLen(1))
+		Expect(objs.Services[0].Spec.Ports[0].Port).To(BeEquivalentTo(9090))
+		Expect(objs.Deployments).To(HaveLen(1))
+		Expect(objs.Gateways).To(HaveLen(1))
+		Expect(objs.Gateways[0].Spec.Listeners).To(HaveLen(1))
+		Expect(objs.GatewayClasses).To(HaveLen(1))
+		Expect(objs.GatewayClasses[0].Namespace).To(BeEmpty())
+		Expect(objs.Routes).To(HaveLen(1))
+		Expect(objs.Routes[0].GetName()).To(Equal("api"))
+
+		Expect(m.Source("Myapp", "apps", "shop")).To(Equal(filepath.Join(dir, "apps/shop.yaml")))
+		Expect(m.Source("Gateway", "infra", "public")).To(Equal(filepath.Join(dir, "infra/gateway.json")))
+		Expect(m.Source("Service", "apps", "api")).To(BeEmpty())
+	})
+
+	It("should fail on invalid and duplicate manifests", func() {
+		write("broken.yaml", "kind: [")
+		_, err := Load("default", dir)
+		Expect(err).To(MatchError(ContainSubstring("broken.yaml")))
+
+		Expect(os.Remove(filepath.Join(dir, "broken.yaml"))).To(Succeed())
+		service := `
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+`
+		write("a.yaml", service)
+		write("b/a.yaml", service)
+		_, err = Load("default", dir)
+		Expect(err).To(MatchError(ContainSubstring("Service default/api is also defined in")))
+	})
+})
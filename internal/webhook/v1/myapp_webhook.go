@@ -0,0 +1,525 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+)
+
+// MaxReplicas is the largest replica count a Myapp may request.
+const MaxReplicas int32 = 100
+
+// defaultRequests are the resource requests set on a Myapp that requests
+// neither a resource nor a limit for it.
+var defaultRequests = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("100m"),
+	corev1.ResourceMemory: resource.MustParse("128Mi"),
+}
+
+// imageReferencePattern matches container image references of the form
+// [registry[:port]/]repository[:tag][@digest], following the grammar used by
+// container runtimes.
+var imageReferencePattern = regexp.MustCompile(`^` +
+	// optional registry host and port
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	// repository path components
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	// optional tag
+	`(?::[\w][\w.-]{0,127})?` +
+	// optional digest
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?` +
+	`$`)
+
+// grpcServicePattern and grpcMethodPattern match the exact gRPC service and
+// method names accepted by GRPCRoutes.
+var (
+	grpcServicePattern = regexp.MustCompile(`^(?i)\.?[a-z_][a-z_0-9]*(\.[a-z_][a-z_0-9]*)*$`)
+	grpcMethodPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z_0-9]*$`)
+)
+
+// nolint:unused
+// log is for logging in this package.
+var myapplog = logf.Log.WithName("myapp-resource")
+
+// Options configures the Myapp webhooks.
+type Options struct {
+	// AllowedGateways lists the Gateways a Myapp may attach its route to.
+	// When empty, every Gateway is allowed.
+	AllowedGateways []types.NamespacedName
+	// DefaultGateway is attached to Myapps whose routing sets no parentRefs.
+	// When nil, parentRefs are not defaulted.
+	DefaultGateway *types.NamespacedName
+}
+
+// SetupMyappWebhookWithManager registers the webhook for Myapp in the manager.
+func SetupMyappWebhookWithManager(mgr ctrl.Manager, opts Options) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&webappv1.Myapp{}).
+		WithValidator(&MyappCustomValidator{AllowedGateways: opts.AllowedGateways}).
+		WithDefaulter(&MyappCustomDefaulter{DefaultGateway: opts.DefaultGateway}).
+		Complete()
+}
+
+// ParseGateway parses a namespace/name Gateway reference, as given to the
+// --default-gateway flag.
+func ParseGateway(value string) (types.NamespacedName, error) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return types.NamespacedName{}, fmt.Errorf("invalid Gateway %q: expected namespace/name", value)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// ParseAllowedGateways parses a comma-separated list of namespace/name Gateway
+// references, as given to the --allowed-gateways flag.
+func ParseAllowedGateways(value string) ([]types.NamespacedName, error) {
+	var gateways []types.NamespacedName
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		gateway, err := ParseGateway(entry)
+		if err != nil {
+			return nil, err
+		}
+		gateways = append(gateways, gateway)
+	}
+	return gateways, nil
+}
+
+// +kubebuilder:webhook:path=/mutate-webapp-my-apps-com-v1-myapp,mutating=true,failurePolicy=fail,sideEffects=None,groups=webapp.my-apps.com,resources=myapps,verbs=create;update,versions=v1,name=mmyapp-v1.kb.io,admissionReviewVersions=v1
+
+// MyappCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind Myapp when those are created or updated.
+type MyappCustomDefaulter struct {
+	// DefaultGateway is attached to Myapps whose routing sets no parentRefs.
+	DefaultGateway *types.NamespacedName
+}
+
+var _ webhook.CustomDefaulter = &MyappCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Myapp.
+func (d *MyappCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	myapp, ok := obj.(*webappv1.Myapp)
+	if !ok {
+		return fmt.Errorf("expected a Myapp object but got %T", obj)
+	}
+	myapplog.Info("Defaulting for Myapp", "name", myapp.GetName())
+
+	// Objects being deleted only get their finalizers removed
+	if myapp.DeletionTimestamp != nil {
+		return nil
+	}
+	d.applyDefaults(myapp)
+	return nil
+}
+
+// applyDefaults fills in the fields of the Myapp spec that were omitted.
+func (d *MyappCustomDefaulter) applyDefaults(myapp *webappv1.Myapp) {
+	spec := &myapp.Spec
+
+	if spec.Port == 0 {
+		spec.Port = webappv1.DefaultPort
+	}
+	if spec.Replicas == nil {
+		spec.Replicas = ptr.To(webappv1.DefaultReplicas)
+	}
+
+	for name, quantity := range defaultRequests {
+		if _, ok := spec.Resources.Requests[name]; ok {
+			continue
+		}
+		// The API server defaults a missing request to the limit
+		if _, ok := spec.Resources.Limits[name]; ok {
+			continue
+		}
+		if spec.Resources.Requests == nil {
+			spec.Resources.Requests = corev1.ResourceList{}
+		}
+		spec.Resources.Requests[name] = quantity.DeepCopy()
+	}
+
+	if spec.LivenessProbe == nil {
+		spec.LivenessProbe = defaultProbe(15, 20)
+	}
+	if spec.ReadinessProbe == nil {
+		spec.ReadinessProbe = defaultProbe(5, 10)
+	}
+
+	if spec.Rollout != nil && spec.Rollout.BlueGreen != nil {
+		blueGreen := spec.Rollout.BlueGreen
+		if blueGreen.AutoPromote == nil {
+			blueGreen.AutoPromote = ptr.To(true)
+		}
+		if blueGreen.ScaleDownDelay == nil {
+			blueGreen.ScaleDownDelay = &metav1.Duration{Duration: webappv1.DefaultScaleDownDelay}
+		}
+	}
+
+	if spec.Routing != nil && len(spec.Routing.ParentRefs) == 0 && d.DefaultGateway != nil {
+		spec.Routing.ParentRefs = []webappv1.ParentRef{{
+			Name:      d.DefaultGateway.Name,
+			Namespace: d.DefaultGateway.Namespace,
+		}}
+	}
+}
+
+// defaultProbe returns a probe checking that the application port, which the
+// controller names "http", accepts TCP connections.
+func defaultProbe(initialDelaySeconds, periodSeconds int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+		},
+		InitialDelaySeconds: initialDelaySeconds,
+		PeriodSeconds:       periodSeconds,
+		TimeoutSeconds:      1,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-webapp-my-apps-com-v1-myapp,mutating=false,failurePolicy=fail,sideEffects=None,groups=webapp.my-apps.com,resources=myapps,verbs=create;update,versions=v1,name=vmyapp-v1.kb.io,admissionReviewVersions=v1
+
+// MyappCustomValidator struct is responsible for validating the Myapp resource
+// when it is created or updated.
+type MyappCustomValidator struct {
+	// AllowedGateways lists the Gateways a Myapp may attach its route to.
+	// When empty, every Gateway is allowed.
+	AllowedGateways []types.NamespacedName
+}
+
+var _ webhook.CustomValidator = &MyappCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Myapp.
+func (v *MyappCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	myapp, ok := obj.(*webappv1.Myapp)
+	if !ok {
+		return nil, fmt.Errorf("expected a Myapp object but got %T", obj)
+	}
+	myapplog.Info("Validation for Myapp upon creation", "name", myapp.GetName())
+
+	return nil, v.validateMyapp(myapp)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Myapp.
+func (v *MyappCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	myapp, ok := newObj.(*webappv1.Myapp)
+	if !ok {
+		return nil, fmt.Errorf("expected a Myapp object for the newObj but got %T", newObj)
+	}
+	myapplog.Info("Validation for Myapp upon update", "name", myapp.GetName())
+
+	// Objects being deleted only get their finalizers removed
+	if myapp.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, v.validateMyapp(myapp)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Myapp.
+func (v *MyappCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	myapp, ok := obj.(*webappv1.Myapp)
+	if !ok {
+		return nil, fmt.Errorf("expected a Myapp object but got %T", obj)
+	}
+	myapplog.Info("Validation for Myapp upon deletion", "name", myapp.GetName())
+
+	return nil, nil
+}
+
+// validateMyapp returns an Invalid error listing every problem found in the
+// Myapp spec, or nil when the spec is valid.
+func (v *MyappCustomValidator) validateMyapp(myapp *webappv1.Myapp) error {
+	specPath := field.NewPath("spec")
+
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateImage(myapp.Spec.Image, specPath.Child("image"))...)
+	allErrs = append(allErrs, validateReplicas(myapp.Spec.Replicas, specPath.Child("replicas"))...)
+	if myapp.Spec.Routing != nil {
+		allErrs = append(allErrs, v.validateRouting(myapp, specPath.Child("routing"))...)
+	}
+	if rollout := myapp.Spec.Rollout; rollout != nil {
+		if rollout.Canary != nil {
+			allErrs = append(allErrs, validateCanary(myapp, specPath.Child("rollout", "canary"))...)
+		}
+		if rollout.BlueGreen != nil {
+			allErrs = append(allErrs, validateBlueGreen(myapp, specPath.Child("rollout", "blueGreen"))...)
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(webappv1.GroupVersion.WithKind("Myapp").GroupKind(), myapp.Name, allErrs)
+}
+
+func validateImage(image string, fldPath *field.Path) field.ErrorList {
+	switch {
+	case image == "":
+		return field.ErrorList{field.Required(fldPath, "an image is required")}
+	case !imageReferencePattern.MatchString(image):
+		return field.ErrorList{field.Invalid(fldPath, image,
+			"must be a valid image reference, e.g. registry.example.com/team/app:1.0")}
+	}
+	return nil
+}
+
+func validateReplicas(replicas *int32, fldPath *field.Path) field.ErrorList {
+	if replicas == nil {
+		return nil
+	}
+	if *replicas < 0 || *replicas > MaxReplicas {
+		return field.ErrorList{field.Invalid(fldPath, *replicas,
+			fmt.Sprintf("must be between 0 and %d", MaxReplicas))}
+	}
+	return nil
+}
+
+func (v *MyappCustomValidator) validateRouting(myapp *webappv1.Myapp, fldPath *field.Path) field.ErrorList {
+	routing := myapp.Spec.Routing
+
+	var allErrs field.ErrorList
+	for i, parentRef := range routing.ParentRefs {
+		namespace := parentRef.Namespace
+		if namespace == "" {
+			namespace = myapp.Namespace
+		}
+		gateway := types.NamespacedName{Namespace: namespace, Name: parentRef.Name}
+		if !v.gatewayAllowed(gateway) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("parentRefs").Index(i),
+				fmt.Sprintf("Gateway %s is not in the list of allowed Gateways", gateway)))
+		}
+	}
+
+	for i, hostname := range routing.Hostnames {
+		allErrs = append(allErrs, validateHostname(hostname, fldPath.Child("hostnames").Index(i))...)
+	}
+
+	protocol := routing.Protocol
+	if protocol == "" {
+		protocol = webappv1.RouteProtocolHTTP
+	}
+	if protocol != webappv1.RouteProtocolHTTP && len(routing.Paths) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("paths"), "paths only apply to the HTTP protocol"))
+	}
+	if protocol != webappv1.RouteProtocolGRPC && len(routing.Methods) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("methods"), "methods only apply to the GRPC protocol"))
+	}
+	if protocol == webappv1.RouteProtocolTCP && len(routing.Hostnames) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("hostnames"), "TCP routes do not match hostnames"))
+	}
+	allErrs = append(allErrs, validateMethods(routing.Methods, fldPath.Child("methods"))...)
+	allErrs = append(allErrs, validateBackendRefs(myapp, routing.BackendRefs, fldPath.Child("backendRefs"))...)
+
+	seen := make(map[webappv1.PathMatch]bool, len(routing.Paths))
+	for i, path := range routing.Paths {
+		pathPath := fldPath.Child("paths").Index(i)
+		if !strings.HasPrefix(path.Value, "/") {
+			allErrs = append(allErrs, field.Invalid(pathPath.Child("value"), path.Value, "must begin with '/'"))
+		}
+		// An empty type matches like PathPrefix once the route is generated
+		if path.Type == "" {
+			path.Type = webappv1.PathMatchPathPrefix
+		}
+		if seen[path] {
+			allErrs = append(allErrs, field.Duplicate(pathPath, fmt.Sprintf("%s %s", path.Type, path.Value)))
+		}
+		seen[path] = true
+	}
+
+	return allErrs
+}
+
+// validateMethods checks gRPC method matches the way GRPCRoutes do: exact
+// matches must name valid services and methods, regular expressions must compile.
+func validateMethods(methods []webappv1.MethodMatch, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[webappv1.MethodMatch]bool, len(methods))
+	for i, method := range methods {
+		methodPath := fldPath.Index(i)
+		if method.Service == "" && method.Method == "" {
+			allErrs = append(allErrs, field.Required(methodPath, "one or both of service and method must be set"))
+			continue
+		}
+		// An empty type matches like Exact once the route is generated
+		if method.Type == "" {
+			method.Type = webappv1.MethodMatchExact
+		}
+		if method.Type == webappv1.MethodMatchExact {
+			if method.Service != "" && !grpcServicePattern.MatchString(method.Service) {
+				allErrs = append(allErrs, field.Invalid(methodPath.Child("service"), method.Service,
+					"must be a fully qualified gRPC service name"))
+			}
+			if method.Method != "" && !grpcMethodPattern.MatchString(method.Method) {
+				allErrs = append(allErrs, field.Invalid(methodPath.Child("method"), method.Method,
+					"must be a gRPC method name"))
+			}
+		} else {
+			if _, err := regexp.Compile(method.Service); err != nil {
+				allErrs = append(allErrs, field.Invalid(methodPath.Child("service"), method.Service, err.Error()))
+			}
+			if _, err := regexp.Compile(method.Method); err != nil {
+				allErrs = append(allErrs, field.Invalid(methodPath.Child("method"), method.Method, err.Error()))
+			}
+		}
+		if seen[method] {
+			allErrs = append(allErrs, field.Duplicate(methodPath,
+				fmt.Sprintf("%s %s/%s", method.Type, method.Service, method.Method)))
+		}
+		seen[method] = true
+	}
+	return allErrs
+}
+
+// validateCanary checks that a canary rollout can shift traffic through the
+// generated route, and that the name of its canary Service is valid.
+func validateCanary(myapp *webappv1.Myapp, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if myapp.Spec.Routing == nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "canary rollouts need routing to shift traffic"))
+		return allErrs
+	}
+	canaryName := myapp.CanaryName()
+	if msgs := validation.IsDNS1035Label(canaryName); len(msgs) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath,
+			fmt.Sprintf("the canary Service name %q is invalid: %s", canaryName, strings.Join(msgs, "; "))))
+	}
+	for i, ref := range myapp.Spec.Routing.BackendRefs {
+		if (ref.Namespace == "" || ref.Namespace == myapp.Namespace) && ref.Name == canaryName {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "routing", "backendRefs").Index(i),
+				"the canary Service is a backend of the route during canary rollouts"))
+		}
+	}
+	return allErrs
+}
+
+// validateBlueGreen checks that a blue/green rollout can switch the generated
+// route, that the names of its color Services are valid, and its preview hostnames.
+func validateBlueGreen(myapp *webappv1.Myapp, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if myapp.Spec.Rollout.Canary != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "canary and blueGreen rollouts are mutually exclusive"))
+	}
+	if myapp.Spec.Routing == nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "blue/green rollouts need routing to switch traffic"))
+		return allErrs
+	}
+	colorName := myapp.ColorName(webappv1.ColorGreen)
+	if msgs := validation.IsDNS1035Label(colorName); len(msgs) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath,
+			fmt.Sprintf("the color Service name %q is invalid: %s", colorName, strings.Join(msgs, "; "))))
+	}
+
+	blueGreen := myapp.Spec.Rollout.BlueGreen
+	if blueGreen.ScaleDownDelay != nil && blueGreen.ScaleDownDelay.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDownDelay"), blueGreen.ScaleDownDelay.Duration.String(),
+			"must not be negative"))
+	}
+	hostnamesPath := fldPath.Child("previewHostnames")
+	if myapp.Spec.Routing.Protocol == webappv1.RouteProtocolTCP && len(blueGreen.PreviewHostnames) > 0 {
+		allErrs = append(allErrs, field.Forbidden(hostnamesPath, "TCP routes do not match hostnames"))
+	}
+	for i, hostname := range blueGreen.PreviewHostnames {
+		allErrs = append(allErrs, validateHostname(hostname, hostnamesPath.Index(i))...)
+	}
+	return allErrs
+}
+
+// validateBackendRefs checks the additional backendRefs of a Myapp: they must
+// name Services, appear once, and not repeat the Myapp Service, which is
+// always a backend of the route.
+func validateBackendRefs(myapp *webappv1.Myapp, backendRefs []webappv1.BackendRef, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(backendRefs))
+	for i, ref := range backendRefs {
+		refPath := fldPath.Index(i)
+		if msgs := validation.IsDNS1035Label(ref.Name); len(msgs) > 0 {
+			allErrs = append(allErrs, field.Invalid(refPath.Child("name"), ref.Name, strings.Join(msgs, "; ")))
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = myapp.Namespace
+		} else if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			allErrs = append(allErrs, field.Invalid(refPath.Child("namespace"), namespace, strings.Join(msgs, "; ")))
+		}
+		if namespace == myapp.Namespace && ref.Name == myapp.Name {
+			allErrs = append(allErrs, field.Forbidden(refPath, "the Myapp Service is always a backend of the route"))
+			continue
+		}
+		key := fmt.Sprintf("%s/%s:%d", namespace, ref.Name, ref.Port)
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(refPath, key))
+		}
+		seen[key] = true
+	}
+	return allErrs
+}
+
+// validateHostname checks a hostname the way Gateway API does: a lowercase
+// DNS subdomain, optionally prefixed with a single "*." wildcard label, and
+// never an IP address.
+func validateHostname(hostname string, fldPath *field.Path) field.ErrorList {
+	if net.ParseIP(hostname) != nil {
+		return field.ErrorList{field.Invalid(fldPath, hostname, "must be a DNS name, not an IP address")}
+	}
+	var msgs []string
+	if strings.HasPrefix(hostname, "*.") {
+		msgs = validation.IsWildcardDNS1123Subdomain(hostname)
+	} else {
+		msgs = validation.IsDNS1123Subdomain(hostname)
+	}
+	if len(msgs) > 0 {
+		return field.ErrorList{field.Invalid(fldPath, hostname, strings.Join(msgs, "; "))}
+	}
+	return nil
+}
+
+func (v *MyappCustomValidator) gatewayAllowed(gateway types.NamespacedName) bool {
+	if len(v.AllowedGateways) == 0 {
+		return true
+	}
+	for _, allowed := range v.AllowedGateways {
+		if allowed == gateway {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	webappv1 "my-apps.com/myapp/api/v1"
+	// TODO (user): Add any additional imports if needed
+)
+
+var _ = Describe("Myapp Webhook", func() {
+	var (
+		obj       *webappv1.Myapp
+		oldObj    *webappv1.Myapp
+		validator MyappCustomValidator
+		defaulter MyappCustomDefaulter
+	)
+
+	// causeFields returns the field paths reported by an Invalid error.
+	causeFields := func(err error) []string {
+		statusErr, ok := err.(*apierrors.StatusError)
+		Expect(ok).To(BeTrue(), "expected a StatusError, got %T", err)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		var fields []string
+		for _, cause := range statusErr.ErrStatus.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		return fields
+	}
+
+	BeforeEach(func() {
+		obj = &webappv1.Myapp{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-webapp", Namespace: "default"},
+			Spec: webappv1.MyappSpec{
+				Image:    "tusova194/my_test_app:1.0.5",
+				Replicas: ptr.To[int32](2),
+				Routing: &webappv1.RoutingSpec{
+					ParentRefs: []webappv1.ParentRef{{Name: "gateway", Namespace: "infra"}},
+					Hostnames:  []string{"app.example.com", "*.apps.example.com"},
+					Paths: []webappv1.PathMatch{
+						{Type: webappv1.PathMatchPathPrefix, Value: "/"},
+						{Type: webappv1.PathMatchExact, Value: "/"},
+					},
+				},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = MyappCustomValidator{}
+		defaulter = MyappCustomDefaulter{}
+	})
+
+	Context("When creating Myapp under Defaulting Webhook", func() {
+		It("Should apply defaults when a five-line Myapp is created", func() {
+			obj.Spec = webappv1.MyappSpec{
+				Image:   "tusova194/my_test_app:1.0.5",
+				Routing: &webappv1.RoutingSpec{},
+			}
+			defaulter.DefaultGateway = &types.NamespacedName{Namespace: "infra", Name: "public"}
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Port).To(Equal(webappv1.DefaultPort))
+			Expect(obj.Spec.Replicas).To(Equal(ptr.To(webappv1.DefaultReplicas)))
+			Expect(obj.Spec.Resources.Requests.Cpu().String()).To(Equal("100m"))
+			Expect(obj.Spec.Resources.Requests.Memory().String()).To(Equal("128Mi"))
+			Expect(obj.Spec.LivenessProbe).NotTo(BeNil())
+			Expect(obj.Spec.LivenessProbe.TCPSocket.Port.StrVal).To(Equal("http"))
+			Expect(obj.Spec.ReadinessProbe).NotTo(BeNil())
+			Expect(obj.Spec.Routing.ParentRefs).To(Equal([]webappv1.ParentRef{{Name: "public", Namespace: "infra"}}))
+
+			By("passing validation once defaulted")
+			validator.AllowedGateways = []types.NamespacedName{{Namespace: "infra", Name: "public"}}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should keep the values that are set", func() {
+			obj.Spec.Port = 3002
+			obj.Spec.Resources = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			}
+			probe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")},
+			}}
+			obj.Spec.ReadinessProbe = probe.DeepCopy()
+			defaulter.DefaultGateway = &types.NamespacedName{Namespace: "infra", Name: "public"}
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Port).To(Equal(int32(3002)))
+			Expect(obj.Spec.Replicas).To(Equal(ptr.To[int32](2)))
+			Expect(obj.Spec.Resources.Requests.Cpu().String()).To(Equal("250m"))
+			By("leaving the memory request to default to its limit")
+			Expect(obj.Spec.Resources.Requests).NotTo(HaveKey(corev1.ResourceMemory))
+			Expect(obj.Spec.ReadinessProbe).To(Equal(probe))
+			Expect(obj.Spec.Routing.ParentRefs).To(Equal([]webappv1.ParentRef{{Name: "gateway", Namespace: "infra"}}))
+		})
+
+		It("Should not default parentRefs without a default Gateway", func() {
+			obj.Spec.Routing.ParentRefs = nil
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Routing.ParentRefs).To(BeEmpty())
+		})
+
+		It("Should default the blue/green settings", func() {
+			obj.Spec.Rollout = &webappv1.RolloutSpec{BlueGreen: &webappv1.BlueGreenStrategy{}}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Rollout.BlueGreen.AutoPromote).To(HaveValue(BeTrue()))
+			Expect(obj.Spec.Rollout.BlueGreen.ScaleDownDelay.Duration).To(Equal(webappv1.DefaultScaleDownDelay))
+		})
+
+		It("Should not add routing to a Myapp without it", func() {
+			obj.Spec.Routing = nil
+			defaulter.DefaultGateway = &types.NamespacedName{Namespace: "infra", Name: "public"}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Routing).To(BeNil())
+		})
+	})
+
+	Context("When creating or updating Myapp under Validating Webhook", func() {
+		It("Should admit a valid Myapp", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
+		})
+
+		It("Should admit image references with a registry, port and digest", func() {
+			for _, image := range []string{
+				"nginx",
+				"registry.example.com:5000/team/app:v1.2.3",
+				"ghcr.io/org/app@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			} {
+				obj.Spec.Image = image
+				_, err := validator.ValidateCreate(ctx, obj)
+				Expect(err).NotTo(HaveOccurred(), image)
+			}
+		})
+
+		It("Should deny creation if the image is not a valid reference", func() {
+			obj.Spec.Image = "Not An Image:"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.image"))
+		})
+
+		It("Should deny creation if replicas are out of range", func() {
+			obj.Spec.Replicas = ptr.To(MaxReplicas + 1)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.replicas"))
+		})
+
+		It("Should deny creation if a hostname is malformed", func() {
+			obj.Spec.Routing.Hostnames = []string{"app.example.com", "Bad_Host", "10.0.0.1"}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.hostnames[1]", "spec.routing.hostnames[2]"))
+		})
+
+		It("Should deny creation if path matches are duplicated", func() {
+			obj.Spec.Routing.Paths = append(obj.Spec.Routing.Paths, webappv1.PathMatch{Value: "/"})
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.paths[2]"))
+		})
+
+		It("Should deny creation if a path does not begin with a slash", func() {
+			obj.Spec.Routing.Paths = []webappv1.PathMatch{{Value: "api"}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.paths[0].value"))
+		})
+
+		It("Should admit gRPC method matches with the GRPC protocol only", func() {
+			obj.Spec.Routing.Protocol = webappv1.RouteProtocolGRPC
+			obj.Spec.Routing.Paths = nil
+			obj.Spec.Routing.Methods = []webappv1.MethodMatch{
+				{Service: "helloworld.Greeter", Method: "SayHello"},
+				{Type: webappv1.MethodMatchRegularExpression, Service: `payments\..*`},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			By("rejecting path matches with the GRPC protocol")
+			obj.Spec.Routing.Paths = []webappv1.PathMatch{{Value: "/"}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.paths"))
+
+			By("rejecting method matches with the HTTP protocol")
+			obj.Spec.Routing.Protocol = webappv1.RouteProtocolHTTP
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.methods"))
+		})
+
+		It("Should only admit hostnames with TLS for layer 4 protocols", func() {
+			obj.Spec.Routing.Protocol = webappv1.RouteProtocolTLS
+			obj.Spec.Routing.Paths = nil
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			obj.Spec.Routing.Protocol = webappv1.RouteProtocolTCP
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.hostnames"))
+
+			obj.Spec.Routing.Hostnames = nil
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should deny creation if gRPC method matches are malformed", func() {
+			obj.Spec.Routing.Protocol = webappv1.RouteProtocolGRPC
+			obj.Spec.Routing.Paths = nil
+			obj.Spec.Routing.Methods = []webappv1.MethodMatch{
+				{},
+				{Service: "hello/world", Method: "Say.Hello"},
+				{Type: webappv1.MethodMatchRegularExpression, Method: "("},
+				{Service: "helloworld.Greeter"},
+				{Type: webappv1.MethodMatchExact, Service: "helloworld.Greeter"},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf(
+				"spec.routing.methods[0]",
+				"spec.routing.methods[1].service",
+				"spec.routing.methods[1].method",
+				"spec.routing.methods[2].method",
+				"spec.routing.methods[4]",
+			))
+		})
+
+		It("Should validate the additional backendRefs", func() {
+			obj.Spec.Routing.BackendRefs = []webappv1.BackendRef{
+				{Name: "legacy", Port: 80},
+				{Name: "payments", Namespace: "shared", Port: 8080, Weight: ptr.To[int32](3)},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			obj.Spec.Routing.BackendRefs = append(obj.Spec.Routing.BackendRefs,
+				webappv1.BackendRef{Name: "legacy", Namespace: "default", Port: 80},
+				webappv1.BackendRef{Name: "my-webapp", Port: 80},
+				webappv1.BackendRef{Name: "Legacy_App", Namespace: "Shared", Port: 80},
+			)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf(
+				"spec.routing.backendRefs[2]",
+				"spec.routing.backendRefs[3]",
+				"spec.routing.backendRefs[4].name",
+				"spec.routing.backendRefs[4].namespace",
+			))
+		})
+
+		It("Should only admit canary rollouts of routed Myapps with a short enough name", func() {
+			obj.Spec.Rollout = &webappv1.RolloutSpec{Canary: &webappv1.CanaryStrategy{
+				Steps: []webappv1.CanaryStep{{Weight: 10}, {Weight: 50}},
+			}}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			obj.Spec.Routing.BackendRefs = []webappv1.BackendRef{{Name: "my-webapp-canary", Port: 80}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.backendRefs[0]"))
+
+			obj.Spec.Routing.BackendRefs = nil
+			obj.Name = strings.Repeat("a", 57)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.rollout.canary"))
+
+			obj.Name = "my-webapp"
+			obj.Spec.Routing = nil
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.rollout.canary"))
+		})
+
+		It("Should validate blue/green rollouts and their preview hostnames", func() {
+			obj.Spec.Rollout = &webappv1.RolloutSpec{BlueGreen: &webappv1.BlueGreenStrategy{
+				PreviewHostnames: []string{"preview.my-apps.com"},
+			}}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			obj.Spec.Rollout.BlueGreen.PreviewHostnames = append(obj.Spec.Rollout.BlueGreen.PreviewHostnames, "-bad-")
+			obj.Spec.Rollout.BlueGreen.ScaleDownDelay = &metav1.Duration{Duration: -time.Second}
+			obj.Spec.Rollout.Canary = &webappv1.CanaryStrategy{Steps: []webappv1.CanaryStep{{Weight: 10}}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causeFields(err)).To(ConsistOf(
+				"spec.rollout.blueGreen",
+				"spec.rollout.blueGreen.scaleDownDelay",
+				"spec.rollout.blueGreen.previewHostnames[1]",
+			))
+		})
+
+		It("Should deny references to Gateways outside the allowed list", func() {
+			validator.AllowedGateways = []types.NamespacedName{{Namespace: "infra", Name: "gateway"}}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			By("defaulting the parentRef namespace to the Myapp namespace")
+			obj.Spec.Routing.ParentRefs = append(obj.Spec.Routing.ParentRefs, webappv1.ParentRef{Name: "gateway"})
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.routing.parentRefs[1]"))
+		})
+
+		It("Should report every invalid field at once", func() {
+			obj.Spec.Image = ""
+			obj.Spec.Replicas = ptr.To[int32](-1)
+			obj.Spec.Routing.Hostnames = []string{"-bad-"}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(causeFields(err)).To(ConsistOf("spec.image", "spec.replicas", "spec.routing.hostnames[0]"))
+		})
+	})
+
+	Context("When parsing the allowed Gateways", func() {
+		It("Should parse a comma-separated namespace/name list", func() {
+			Expect(ParseAllowedGateways("")).To(BeEmpty())
+			Expect(ParseAllowedGateways("infra/public, infra/internal")).To(Equal([]types.NamespacedName{
+				{Namespace: "infra", Name: "public"},
+				{Namespace: "infra", Name: "internal"},
+			}))
+		})
+
+		It("Should reject entries without a namespace", func() {
+			_, err := ParseAllowedGateways("public")
+			Expect(err).To(HaveOccurred())
+			_, err = ParseGateway("infra/public/http")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})